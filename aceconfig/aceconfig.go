@@ -0,0 +1,127 @@
+// Package aceconfig loads the configuration files of the project.  Each key of a file may be overridden by an
+// environment variable named GASKET_ followed by the key in upper case with dots replaced by underscores,
+// ie GASKET_DB_SERVER for db.server, and by command line flags
+package aceconfig
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding configuration keys
+const EnvPrefix = "GASKET"
+
+// PathEnv is the environment variable naming the folder holding the configuration files
+const PathEnv = "ACES_CFG"
+
+var (
+	pathMu sync.Mutex
+	path   string
+)
+
+// SetPath will set the folder the configuration files are read from, in place of the folder named by ACES_CFG
+func SetPath(dir string) {
+	pathMu.Lock()
+	defer pathMu.Unlock()
+	path = dir
+}
+
+// searchPaths will return the folders searched for configuration files
+func searchPaths() []string {
+	pathMu.Lock()
+	defer pathMu.Unlock()
+	if path != "" {
+		return []string{path}
+	}
+	if _, err := os.Stat(os.Getenv(PathEnv)); err == nil {
+		return []string{os.Getenv(PathEnv)}
+	}
+	return []string{"./config", "./../config"}
+}
+
+// New will return a viper instance for the named configuration file with the defaults set and environment
+// variables bound.  The file is not read
+func New(name string, defaults map[string]interface{}) *viper.Viper {
+	v := viper.New()
+	v.SetConfigName(name) // no need to include file extension
+	for _, dir := range searchPaths() {
+		v.AddConfigPath(dir)
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	return v
+}
+
+// Load will read the named configuration file, ie "config" for config.toml.  The environment variable of a key takes
+// precedence over the file, and the file over the defaults.  The instance is returned along with the error
+// when the file cannot be read, so that the defaults and environment remain usable
+func Load(name string, defaults map[string]interface{}) (*viper.Viper, error) {
+	v := New(name, defaults)
+	return v, v.ReadInConfig()
+}
+
+// Overrides are configuration values set on the command line with -set key=value.  It implements flag.Value
+type Overrides map[string]string
+
+func (o Overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for key, value := range o {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set will add a key=value pair
+func (o Overrides) Set(pair string) error {
+	i := strings.Index(pair, "=")
+	if i <= 0 {
+		return fmt.Errorf("%q is not of the form key=value", pair)
+	}
+	o[strings.ToLower(pair[:i])] = pair[i+1:]
+	return nil
+}
+
+// Apply will set the overrides on v, where they take precedence over the file, environment and defaults
+func (o Overrides) Apply(v *viper.Viper) {
+	for key, value := range o {
+		v.Set(key, value)
+	}
+}
+
+// secretKeys are the key names whose values are left out of Redacted
+var secretKeys = []string{"password", "secret", "uri", "token"}
+
+// Redacted will return the effective value of every configuration key, with the values of keys naming
+// a password, secret, uri or token replaced
+func Redacted(v *viper.Viper) map[string]interface{} {
+	settings := make(map[string]interface{})
+	for _, key := range v.AllKeys() {
+		value := v.Get(key)
+		if isSecret(key) && fmt.Sprint(value) != "" {
+			value = "[redacted]"
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// isSecret will return true if the last part of the key names a secret
+func isSecret(key string) bool {
+	name := key[strings.LastIndex(key, ".")+1:]
+	for _, secret := range secretKeys {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
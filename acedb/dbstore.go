@@ -2,73 +2,295 @@ package dbhandle
 
 import (
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
-	log "github.com/gkontos/gasket/acelog"
 	"github.com/cayleygraph/cayley"
 	"github.com/cayleygraph/cayley/graph"
+	_ "github.com/cayleygraph/cayley/graph/bolt"
+	_ "github.com/cayleygraph/cayley/graph/leveldb"
+	_ "github.com/cayleygraph/cayley/graph/memstore"
 	_ "github.com/cayleygraph/cayley/graph/mongo"
+	log "github.com/gkontos/gasket/acelog"
 	"github.com/spf13/viper"
 )
 
+// DefaultBackend is the cayley backend used when db.backend is not configured
+const DefaultBackend = "mongo"
+
+// Defaults for the [db.retry] block of the configuration
+const (
+	DefaultRetryAttempts        = 5
+	DefaultRetryInitialInterval = 500 * time.Millisecond
+	DefaultRetryMaxInterval     = 30 * time.Second
+	DefaultRetryDrainTimeout    = time.Minute
+)
+
 type Store interface {
 	GetStore() (*cayley.Handle, error)
 	SetConfig(conf *viper.Viper)
+	Backend() string
+	Validate() error
+	Reconnectable() bool
+	Reconnect() error
+	Monitor(interval time.Duration, check func() error, stop <-chan struct{})
 }
 
 type graphStore struct {
+	mu      sync.Mutex // serializes connecting to the backend
 	dbstore *cayley.Handle
 	config  *viper.Viper
-}
 
-var (
-	once sync.Once
-)
+	// the connection in use, read through the handle returned by GetStore for backends which can reconnect
+	connMu  sync.RWMutex
+	current *cayley.Handle
+
+	open  func(backend string, addr string, opts graph.Options) (*cayley.Handle, error)
+	sleep func(time.Duration)
+}
 
 func New() *graphStore {
 
-	gs := &graphStore{}
+	gs := &graphStore{sleep: time.Sleep}
+	gs.open = gs.openBackend
 	return gs
 }
 
 func (repo *graphStore) SetConfig(conf *viper.Viper) {
 	repo.config = conf
 }
+
+// GetStore will return a handle for the configured backend, connecting on the first call.  The connection is retried
+// with the backoff of the [db.retry] block, and when every attempt fails the error is returned so that a later call
+// may try again
 func (repo *graphStore) GetStore() (*cayley.Handle, error) {
 	if repo.config == nil {
 		return nil, fmt.Errorf("Configuration for datastore not set")
 	}
-	var err error
-	once.Do(func() {
-		// repo = &graphStore{}
-		err = repo.configure()
-	})
-	return repo.dbstore, err
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.dbstore == nil {
+		handle, err := repo.connect()
+		if err != nil {
+			return nil, err
+		}
+		if !repo.Reconnectable() {
+			repo.dbstore = handle
+			return repo.dbstore, nil
+		}
+		repo.setConnection(handle)
+		repo.dbstore = &cayley.Handle{QuadStore: storeProxy{repo}, QuadWriter: writerProxy{repo}}
+	}
+	return repo.dbstore, nil
+}
+
+// Reconnectable will return true if the backend is reached over the network, so that a lost connection may be reopened.
+// The file backends hold a lock on their files and memstore would be replaced by an empty graph, so neither is reopened
+func (repo *graphStore) Reconnectable() bool {
+	return repo.Backend() == "mongo"
 }
 
-// config will return a cayley.Handle for a mongo database
-func (repo *graphStore) configure() error {
+// connection will return the connection in use
+func (repo *graphStore) connection() *cayley.Handle {
+	repo.connMu.RLock()
+	defer repo.connMu.RUnlock()
+	return repo.current
+}
+
+// setConnection will replace the connection in use, returning the previous connection
+func (repo *graphStore) setConnection(handle *cayley.Handle) *cayley.Handle {
+	repo.connMu.Lock()
+	defer repo.connMu.Unlock()
+	previous := repo.current
+	repo.current = handle
+	return previous
+}
 
+// Validate will return an error if the configuration of the backend is incomplete.  The store is not opened
+func (repo *graphStore) Validate() error {
+	if repo.config == nil {
+		return fmt.Errorf("Configuration for datastore not set")
+	}
+	_, _, err := repo.backendOptions(repo.Backend())
+	return err
+}
+
+// Reconnect will open a new connection to the backend.  Each call made through the handle returned by GetStore is passed
+// to the connection in use at the time of the call, so the services holding the handle move to the new connection.
+// The previous connection is closed after db.retry.drain_timeout, giving the requests and iterators still using it
+// time to complete.  Only backends for which Reconnectable is true may reconnect, and the connection is left unchanged
+// if the backend cannot be reached
+func (repo *graphStore) Reconnect() error {
+	if repo.config == nil {
+		return fmt.Errorf("Configuration for datastore not set")
+	}
+	if !repo.Reconnectable() {
+		return fmt.Errorf("Reconnecting is not supported for the %s backend", repo.Backend())
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	handle, err := repo.connect()
+	if err != nil {
+		return err
+	}
 	if repo.dbstore == nil {
+		repo.setConnection(handle)
+		repo.dbstore = &cayley.Handle{QuadStore: storeProxy{repo}, QuadWriter: writerProxy{repo}}
+		return nil
+	}
+	if previous := repo.setConnection(handle); previous != nil {
+		time.AfterFunc(repo.duration("db.retry.drain_timeout", DefaultRetryDrainTimeout), previous.Close)
+	}
+	log.Info("Reconnected to ", repo.Backend(), " quad store")
+	return nil
+}
 
-		server := repo.config.GetString("db.server")
-		port := repo.config.GetString("db.port")
-		opts := make(graph.Options)
-		//		opts["username"] = ""
-		//		opts["password"] = ""
-		//		opts["database_name"] = "cayley"
+// Monitor will call check every interval until stop is closed, reconnecting to the backend each time check fails.
+// It returns at once for backends which cannot reconnect
+func (repo *graphStore) Monitor(interval time.Duration, check func() error, stop <-chan struct{}) {
+	if !repo.Reconnectable() {
+		log.Info("Datastore monitoring is not supported for the ", repo.Backend(), " backend")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := check(); err != nil {
+				log.Warn("Datastore check failed, reconnecting - ", err)
+				if err = repo.Reconnect(); err != nil {
+					log.Error("Unable to reconnect to datastore - ", err)
+				}
+			}
+		}
+	}
+}
+
+// Backend will return the name of the configured cayley backend
+func (repo *graphStore) Backend() string {
+	backend := repo.config.GetString("db.backend")
+	if backend == "" {
+		return DefaultBackend
+	}
+	return backend
+}
 
-		// Create a brand new graph
-		addr := server + ":" + port
-		graph.InitQuadStore("mongo", addr, opts)
+// duration will return the configured duration for key, or def when the key is not set
+func (repo *graphStore) duration(key string, def time.Duration) time.Duration {
+	if !repo.config.IsSet(key) {
+		return def
+	}
+	return repo.config.GetDuration(key)
+}
+
+// connect will return a cayley.Handle for the configured backend.  A failed attempt is retried up to db.retry.attempts
+// times in all, waiting db.retry.initial_interval after the first failure and doubling the wait up to db.retry.max_interval
+func (repo *graphStore) connect() (*cayley.Handle, error) {
+	backend := repo.Backend()
+	addr, opts, err := repo.backendOptions(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := DefaultRetryAttempts
+	if repo.config.IsSet("db.retry.attempts") {
+		attempts = repo.config.GetInt("db.retry.attempts")
+	}
+	wait := repo.duration("db.retry.initial_interval", DefaultRetryInitialInterval)
+	maxWait := repo.duration("db.retry.max_interval", DefaultRetryMaxInterval)
+
+	for attempt := 1; ; attempt++ {
+		handle, err := repo.open(backend, addr, opts)
+		if err == nil {
+			return handle, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("Unable to open %s quad store after %d attempts : %v", backend, attempt, err)
+		}
+		log.Warn("Unable to open ", backend, " quad store, retrying in ", wait, " - ", err)
+		repo.sleep(wait)
+		if wait *= 2; wait > maxWait {
+			wait = maxWait
+		}
+	}
+}
 
-		var err error
-		repo.dbstore, err = cayley.NewGraph("mongo", addr, opts)
+// openBackend will return a cayley.Handle for the backend, creating the store first if it does not exist
+func (repo *graphStore) openBackend(backend string, addr string, opts graph.Options) (*cayley.Handle, error) {
+	// Create a brand new graph
+	if repo.needsInit(backend, addr) {
+		log.Info("Initializing ", backend, " quad store at ", addr)
+		if err := graph.InitQuadStore(backend, addr, opts); err != nil {
+			return nil, err
+		}
+	}
+	return cayley.NewGraph(backend, addr, opts)
+}
+
+// backendOptions will return the address and the graph.Options for a backend.
+// The options are read from the [db.<backend>] block of the configuration
+func (repo *graphStore) backendOptions(backend string) (string, graph.Options, error) {
+	opts := make(graph.Options)
+	prefix := "db." + backend
+
+	for key := range repo.config.GetStringMap(prefix) {
+		switch key {
+		case "path", "server", "port", "uri":
+			// used to build the address, not passed to cayley
+		default:
+			// read by key so that environment variables take precedence over the file
+			opts[key] = toOptionValue(repo.config.Get(prefix + "." + key))
+		}
+	}
+
+	switch backend {
+	case "mongo":
+		addr, err := repo.mongoAddress(opts)
 		if err != nil {
-			log.Fatal(err)
-			return err
+			return "", nil, err
 		}
+		return addr, opts, nil
+	case "bolt", "leveldb":
+		path := repo.config.GetString(prefix + ".path")
+		if path == "" {
+			return "", nil, fmt.Errorf("Database path not configured for %s", backend)
+		}
+		return path, opts, nil
+	case "memstore":
+		return "", opts, nil
+	default:
+		return "", nil, fmt.Errorf("Unsupported database backend %s", backend)
+	}
+}
 
+// needsInit will return true when the store for a persistent backend has not been created yet.
+// mongo ensures its indexes whenever a connection is opened, so it never requires an init
+func (repo *graphStore) needsInit(backend string, addr string) bool {
+	if !graph.IsPersistent(backend) {
+		return false
 	}
-	return nil
+	switch backend {
+	case "bolt", "leveldb":
+		_, err := os.Stat(addr)
+		return os.IsNotExist(err)
+	}
+	return false
+}
+
+// toOptionValue converts configuration values to the types expected by graph.Options.
+// cayley reads all numeric options as float64
+func toOptionValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
 }
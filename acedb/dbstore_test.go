@@ -0,0 +1,264 @@
+package dbhandle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestStore will return a graphStore for the backend whose open fails the first failures calls and whose
+// sleeps are recorded rather than waited
+func makeTestStore(backend string, failures int) (*graphStore, *[]time.Duration, *int) {
+	v := viper.New()
+	v.Set("db.backend", backend)
+	v.Set("db.server", "localhost")
+	v.Set("db.retry.attempts", 4)
+	v.Set("db.retry.initial_interval", "100ms")
+	v.Set("db.retry.max_interval", "250ms")
+
+	repo := New()
+	repo.SetConfig(v)
+	sleeps := []time.Duration{}
+	opens := 0
+	repo.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	repo.open = func(backend string, addr string, opts graph.Options) (*cayley.Handle, error) {
+		opens++
+		if opens <= failures {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return cayley.NewMemoryGraph()
+	}
+	return repo, &sleeps, &opens
+}
+
+func TestConnectRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, sleeps, opens := makeTestStore("memstore", 10)
+	_, err := repo.GetStore()
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "after 4 attempts")
+		assert.Contains(err.Error(), "connection refused")
+	}
+	assert.Equal(4, *opens)
+	assert.Equal([]time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond}, *sleeps)
+
+	repo, sleeps, opens = makeTestStore("memstore", 2)
+	handle, err := repo.GetStore()
+	assert.NoError(err)
+	assert.NotNil(handle)
+	assert.Equal(3, *opens)
+	assert.Equal([]time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *sleeps)
+}
+
+func TestGetStoreAfterFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, _, opens := makeTestStore("memstore", 4)
+	_, err := repo.GetStore()
+	assert.Error(err)
+	assert.Equal(4, *opens)
+
+	handle, err := repo.GetStore()
+	assert.NoError(err)
+	assert.NotNil(handle)
+	assert.Equal(5, *opens)
+
+	// later calls return the same handle without opening the store again
+	again, err := repo.GetStore()
+	assert.NoError(err)
+	assert.True(handle == again)
+	assert.Equal(5, *opens)
+}
+
+func TestReconnect(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, _, opens := makeTestStore("memstore", 0)
+	assert.False(repo.Reconnectable())
+	_, err := repo.GetStore()
+	assert.NoError(err)
+	assert.Error(repo.Reconnect())
+	assert.Equal(1, *opens)
+
+	repo, _, opens = makeTestStore("mongo", 0)
+	repo.config.Set("db.retry.drain_timeout", "10ms")
+	assert.True(repo.Reconnectable())
+	handle, err := repo.GetStore()
+	assert.NoError(err)
+	assert.NoError(handle.AddQuad(quad.Make("a", "follows", "b", nil)))
+	assert.Equal(int64(1), handle.Size())
+
+	// the handle moves to the new connection, which is empty in this test
+	assert.NoError(repo.Reconnect())
+	assert.Equal(2, *opens)
+	assert.Equal(int64(0), handle.Size())
+	assert.NoError(handle.AddQuad(quad.Make("c", "follows", "d", nil)))
+	assert.Equal(int64(1), handle.Size())
+
+	// the connection is left unchanged when the backend cannot be reached
+	repo.open = func(backend string, addr string, opts graph.Options) (*cayley.Handle, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	assert.Error(repo.Reconnect())
+	assert.Equal(int64(1), handle.Size())
+}
+
+func TestBackendOptions(t *testing.T) {
+	tests := []struct {
+		Description  string
+		Backend      string
+		Config       map[string]interface{}
+		ExpectedAddr string
+		ExpectedOpts graph.Options
+		ExpectedErr  string
+	}{
+		{
+			Description:  "memstore",
+			Backend:      "memstore",
+			Config:       map[string]interface{}{},
+			ExpectedOpts: graph.Options{},
+		}, {
+			Description:  "bolt path",
+			Backend:      "bolt",
+			Config:       map[string]interface{}{"db.bolt.path": "/var/lib/gasket/gasket.db", "db.bolt.nosync": true},
+			ExpectedAddr: "/var/lib/gasket/gasket.db",
+			ExpectedOpts: graph.Options{"nosync": true},
+		}, {
+			Description:  "leveldb path",
+			Backend:      "leveldb",
+			Config:       map[string]interface{}{"db.leveldb.path": "/var/lib/gasket/leveldb", "db.leveldb.cache_size_mb": 4},
+			ExpectedAddr: "/var/lib/gasket/leveldb",
+			ExpectedOpts: graph.Options{"cache_size_mb": float64(4)},
+		}, {
+			Description: "bolt without a path",
+			Backend:     "bolt",
+			Config:      map[string]interface{}{"db.bolt.nosync": true},
+			ExpectedErr: "Database path not configured for bolt",
+		}, {
+			Description: "leveldb without a path",
+			Backend:     "leveldb",
+			Config:      map[string]interface{}{},
+			ExpectedErr: "Database path not configured for leveldb",
+		}, {
+			Description:  "mongo",
+			Backend:      "mongo",
+			Config:       map[string]interface{}{"db.server": "mongo1", "db.port": "27017", "db.mongo.database_name": "cayley"},
+			ExpectedAddr: "mongo1:27017",
+			ExpectedOpts: graph.Options{"database_name": "cayley"},
+		}, {
+			Description: "mongo replica set, which cayley cannot be given",
+			Backend:     "mongo",
+			Config:      map[string]interface{}{"db.server": "mongo1", "db.mongo.replica_set": "rs0"},
+			ExpectedErr: "A replica set name cannot be given",
+		}, {
+			Description: "unsupported backend",
+			Backend:     "sql",
+			Config:      map[string]interface{}{"db.sql.path": "gasket"},
+			ExpectedErr: "Unsupported database backend sql",
+		},
+	}
+
+	for _, tc := range tests {
+		v := viper.New()
+		for key, value := range tc.Config {
+			v.Set(key, value)
+		}
+		repo := New()
+		repo.SetConfig(v)
+		addr, opts, err := repo.backendOptions(tc.Backend)
+		if tc.ExpectedErr != "" {
+			if assert.Error(t, err, tc.Description) {
+				assert.Contains(t, err.Error(), tc.ExpectedErr, tc.Description)
+			}
+			continue
+		}
+		assert.NoError(t, err, tc.Description)
+		assert.Equal(t, tc.ExpectedAddr, addr, tc.Description)
+		assert.Equal(t, tc.ExpectedOpts, opts, tc.Description)
+	}
+}
+
+func TestNeedsInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gasket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		Backend  string
+		Addr     string
+		Expected bool
+	}{
+		{"bolt", filepath.Join(dir, "missing.db"), true},
+		{"bolt", dir, false},
+		{"leveldb", filepath.Join(dir, "missing"), true},
+		{"leveldb", dir, false},
+		{"memstore", "", false},
+		{"mongo", "localhost:27017", false},
+	}
+	repo := New()
+	repo.SetConfig(viper.New())
+	for _, tc := range tests {
+		assert.Equal(t, tc.Expected, repo.needsInit(tc.Backend, tc.Addr), tc.Backend+" "+tc.Addr)
+	}
+}
+
+func TestToOptionValue(t *testing.T) {
+	tests := []struct {
+		Value    interface{}
+		Expected interface{}
+	}{
+		{int(4), float64(4)},
+		{int64(4), float64(4)},
+		{float32(0.5), float64(0.5)},
+		{float64(0.5), float64(0.5)},
+		{"cayley", "cayley"},
+		{true, true},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.Expected, toOptionValue(tc.Value), fmt.Sprint(tc.Value))
+	}
+}
+
+func TestOpenBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gasket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, backend := range []string{"bolt", "leveldb"} {
+		v := viper.New()
+		v.Set("db.backend", backend)
+		v.Set("db."+backend+".path", filepath.Join(dir, backend))
+		repo := New()
+		repo.SetConfig(v)
+
+		// the store is created on the first open and its quads are read back on the next one
+		handle, err := repo.GetStore()
+		if !assert.NoError(t, err, backend) {
+			continue
+		}
+		assert.NoError(t, handle.AddQuad(quad.Make("a", "follows", "b", nil)), backend)
+		handle.Close()
+
+		repo = New()
+		repo.SetConfig(v)
+		handle, err = repo.GetStore()
+		if assert.NoError(t, err, backend) {
+			assert.Equal(t, int64(1), handle.Size(), backend)
+			handle.Close()
+		}
+	}
+}
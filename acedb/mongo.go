@@ -0,0 +1,103 @@
+package dbhandle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cayleygraph/cayley/graph"
+	"gopkg.in/mgo.v2"
+)
+
+// mongoOptions are the keys of the [db.mongo] block passed to cayley as graph.Options.  There are no keys for a replica
+// set or an auth source: the cayley mongo store dials mongo itself from the servers, credentials and database, and
+// offers no way to set either
+var mongoOptions = []string{"username", "password", "database_name"}
+
+// mongoSecrets are the keys of the [db.mongo] block which may be read from the environment, so that they need not
+// be written to config.toml.  The variable for a key is GASKET_DB_MONGO_ followed by the key in upper case
+var mongoSecrets = []string{"uri", "username", "password"}
+
+// mongoEnv will return the environment variable read for a key of the [db.mongo] block
+func mongoEnv(key string) string {
+	return "GASKET_DB_MONGO_" + strings.ToUpper(key)
+}
+
+// mongoAddress will return the address of the mongo servers and set the connection options.  The servers, credentials
+// and database found in db.mongo.uri take the place of the keys of the same meaning.  An error is returned for a uri
+// naming a replica set, or an auth source other than the database, as cayley would silently ignore them
+func (repo *graphStore) mongoAddress(opts graph.Options) (string, error) {
+	const prefix = "db.mongo"
+	for _, key := range mongoSecrets {
+		if err := repo.config.BindEnv(prefix+"."+key, mongoEnv(key)); err != nil {
+			return "", err
+		}
+	}
+	for _, key := range mongoOptions {
+		if value := repo.config.GetString(prefix + "." + key); value != "" {
+			opts[key] = value
+		}
+	}
+
+	var addr string
+	if uri := repo.config.GetString(prefix + ".uri"); uri != "" {
+		info, err := mgo.ParseURL(uri)
+		if err != nil {
+			// the uri is left out of the error as it may hold a password
+			return "", fmt.Errorf("Invalid db.mongo.uri : unable to parse connection string")
+		}
+		addr = strings.Join(info.Addrs, ",")
+		for key, value := range map[string]string{
+			"username":      info.Username,
+			"password":      info.Password,
+			"database_name": info.Database,
+			"replica_set":   info.ReplicaSetName,
+			"auth_source":   info.Source,
+		} {
+			if value != "" {
+				opts[key] = value
+			}
+		}
+	} else {
+		// db.server and db.port are supported for configurations which predate the [db.mongo] block
+		server := repo.config.GetString("db.server")
+		port := repo.config.GetString("db.port")
+		if repo.config.IsSet(prefix + ".server") {
+			server = repo.config.GetString(prefix + ".server")
+		}
+		if repo.config.IsSet(prefix + ".port") {
+			port = repo.config.GetString(prefix + ".port")
+		}
+		if server == "" {
+			return "", fmt.Errorf("Database server not configured for mongo")
+		}
+		addr = mongoHosts(server, port)
+	}
+
+	if _, ok := opts["password"]; ok {
+		if _, ok = opts["username"]; !ok {
+			return "", fmt.Errorf("db.mongo.password is set without a username")
+		}
+	}
+	// cayley dials mongo with the servers, credentials and database only.  Its session follows the members of a replica
+	// set found from the servers without checking the set name, and authenticates against the database
+	if _, ok := opts["replica_set"]; ok {
+		return "", fmt.Errorf("A replica set name cannot be given to the cayley mongo store, list the members of the set in db.mongo.server")
+	}
+	if source, ok := opts["auth_source"]; ok && source != opts["database_name"] {
+		return "", fmt.Errorf("An auth source cannot be given to the cayley mongo store, which authenticates against database_name")
+	}
+	return addr, nil
+}
+
+// mongoHosts will return the comma separated list of servers with the port added to each server which does not name one
+func mongoHosts(servers string, port string) string {
+	hosts := strings.Split(servers, ",")
+	for i, host := range hosts {
+		host = strings.TrimSpace(host)
+		if port != "" && !strings.Contains(host, ":") {
+			host += ":" + port
+		}
+		hosts[i] = host
+	}
+	return strings.Join(hosts, ",")
+}
@@ -0,0 +1,58 @@
+package dbhandle
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+)
+
+// storeProxy is the graph.QuadStore of the handle returned by GetStore for backends which can reconnect.  Each call
+// is passed to the connection in use at the time of the call
+type storeProxy struct {
+	repo *graphStore
+}
+
+func (p storeProxy) store() graph.QuadStore {
+	return p.repo.connection().QuadStore
+}
+
+func (p storeProxy) ApplyDeltas(deltas []graph.Delta, opts graph.IgnoreOpts) error {
+	return p.store().ApplyDeltas(deltas, opts)
+}
+func (p storeProxy) Quad(v graph.Value) quad.Quad { return p.store().Quad(v) }
+func (p storeProxy) QuadIterator(d quad.Direction, v graph.Value) graph.Iterator {
+	return p.store().QuadIterator(d, v)
+}
+func (p storeProxy) NodesAllIterator() graph.Iterator   { return p.store().NodesAllIterator() }
+func (p storeProxy) QuadsAllIterator() graph.Iterator   { return p.store().QuadsAllIterator() }
+func (p storeProxy) ValueOf(v quad.Value) graph.Value   { return p.store().ValueOf(v) }
+func (p storeProxy) NameOf(v graph.Value) quad.Value    { return p.store().NameOf(v) }
+func (p storeProxy) Size() int64                        { return p.store().Size() }
+func (p storeProxy) Horizon() graph.PrimaryKey          { return p.store().Horizon() }
+func (p storeProxy) FixedIterator() graph.FixedIterator { return p.store().FixedIterator() }
+func (p storeProxy) OptimizeIterator(it graph.Iterator) (graph.Iterator, bool) {
+	return p.store().OptimizeIterator(it)
+}
+func (p storeProxy) Close() { p.store().Close() }
+func (p storeProxy) QuadDirection(id graph.Value, d quad.Direction) graph.Value {
+	return p.store().QuadDirection(id, d)
+}
+func (p storeProxy) Type() string { return p.store().Type() }
+
+// writerProxy is the graph.QuadWriter of the handle returned by GetStore for backends which can reconnect.  Each call
+// is passed to the connection in use at the time of the call
+type writerProxy struct {
+	repo *graphStore
+}
+
+func (p writerProxy) writer() graph.QuadWriter {
+	return p.repo.connection().QuadWriter
+}
+
+func (p writerProxy) AddQuad(q quad.Quad) error          { return p.writer().AddQuad(q) }
+func (p writerProxy) AddQuadSet(quads []quad.Quad) error { return p.writer().AddQuadSet(quads) }
+func (p writerProxy) RemoveQuad(q quad.Quad) error       { return p.writer().RemoveQuad(q) }
+func (p writerProxy) ApplyTransaction(tx *graph.Transaction) error {
+	return p.writer().ApplyTransaction(tx)
+}
+func (p writerProxy) RemoveNode(v graph.Value) error { return p.writer().RemoveNode(v) }
+func (p writerProxy) Close() error                   { return p.writer().Close() }
@@ -1,12 +1,15 @@
 package acelog
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"sync"
 	"time"
 
 	logrus "github.com/Sirupsen/logrus"
+	"github.com/gkontos/gasket/aceconfig"
+	"github.com/pborman/uuid"
 	"github.com/spf13/viper"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -14,7 +17,6 @@ import (
 // TODO "github.com/lestrrat/go-apache-logformat"
 
 // TODO change log file configuration for test, dev, prod
-// TODO use environment variable for config file location, ie os.Getenv("PROJECT_CFG")
 var (
 	logger      *logrus.Logger
 	once        sync.Once
@@ -32,8 +34,7 @@ type LogConfig struct {
 	maxage     int //days
 }
 type aceLoggingHandler struct {
-	handler      http.Handler
-	accessLogger *logrus.Logger
+	handler http.Handler
 }
 
 func init() {
@@ -46,15 +47,7 @@ func initLogger(configPrefix string) *logrus.Logger {
 
 	logrus.Info("setting up logger for ", configPrefix)
 
-	v := viper.New()
-	v.SetConfigName("log") // no need to include file extension
-	if _, err := os.Stat(os.Getenv("ACES_CFG")); err == nil {
-		v.AddConfigPath(os.Getenv("ACES_CFG"))
-	} else {
-		v.AddConfigPath("./config") // set the path of your config file
-		v.AddConfigPath("./../config")
-	}
-	err := v.ReadInConfig()
+	v, err := readConfig()
 	var config *LogConfig
 	if err != nil {
 		config = &LogConfig{
@@ -75,6 +68,11 @@ func initLogger(configPrefix string) *logrus.Logger {
 	return l
 }
 
+// readConfig will read log.toml from the configuration folder, with keys overridden by GASKET_ environment variables
+func readConfig() (*viper.Viper, error) {
+	return aceconfig.Load("log", nil)
+}
+
 // set the logger instance configuration
 func Configure(l *logrus.Logger, config *LogConfig) {
 	switch config.format {
@@ -134,59 +132,184 @@ func setConfiguration(v *viper.Viper, prefix string) *LogConfig {
 }
 
 func Info(args ...interface{}) {
-	logger.Info(args...)
+	applicationLogger().Info(args...)
 }
 
 func Debug(args ...interface{}) {
-	logger.Debug(args...)
+	applicationLogger().Debug(args...)
 }
 
 func Warn(args ...interface{}) {
-	logger.Warn(args...)
+	applicationLogger().Warn(args...)
 }
 
 func Error(args ...interface{}) {
-	logger.Error(args...)
+	applicationLogger().Error(args...)
 }
 
 func Fatal(args ...interface{}) {
-	logger.Fatal(args...)
+	applicationLogger().Fatal(args...)
+}
+
+// Fields are the structured values written with a log entry
+type Fields map[string]interface{}
+
+// Logger writes entries carrying a set of fields to the application log
+type Logger struct {
+	entry *logrus.Entry
 }
 
+// WithFields will return a Logger writing the fields with each entry
+func WithFields(fields Fields) *Logger {
+	return &Logger{entry: applicationLogger().WithFields(logrus.Fields(fields))}
+}
+
+// WithContext will return a Logger writing the fields carried by ctx, such as the request id, user and route of a request
+func WithContext(ctx context.Context) *Logger {
+	return WithFields(contextFields(ctx))
+}
+
+// WithFields will return a Logger writing the fields along with the fields of l
+func (l *Logger) WithFields(fields Fields) *Logger {
+	return &Logger{entry: l.entry.WithFields(logrus.Fields(fields))}
+}
+
+// WithField will return a Logger writing the field along with the fields of l
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return &Logger{entry: l.entry.WithField(key, value)}
+}
+
+func (l *Logger) Info(args ...interface{}) {
+	l.entry.Info(args...)
+}
+
+func (l *Logger) Debug(args ...interface{}) {
+	l.entry.Debug(args...)
+}
+
+func (l *Logger) Warn(args ...interface{}) {
+	l.entry.Warn(args...)
+}
+
+func (l *Logger) Error(args ...interface{}) {
+	l.entry.Error(args...)
+}
+
+type fieldsKey struct{}
+
+// ContextWithFields will return a copy of ctx carrying the fields along with the fields ctx already carries.  Loggers
+// returned from WithContext write the fields
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	merged := Fields{}
+	for key, value := range contextFields(ctx) {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// contextFields will return the fields carried by ctx
+func contextFields(ctx context.Context) Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// RequestIDHeader is the response header holding the id generated for each request
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID will return the id of the request handled with ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := contextFields(ctx)["request_id"].(string)
+	return id
+}
+
+// WithRequestID will return a copy of ctx carrying the request id as the request_id field
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return ContextWithFields(ctx, Fields{"request_id": id})
+}
+
+// ResponseWriter records the status and the number of bytes written to a response
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// NewResponseWriter will return a ResponseWriter recording the response written to w
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader will record the status and send it to the underlying writer
+func (w *ResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write will count the bytes written to the underlying writer
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Status will return the status of the response.  http.StatusOK is returned when nothing has been written
+func (w *ResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size will return the number of bytes written to the response body
+func (w *ResponseWriter) Size() int {
+	return w.size
+}
+
+// RequestLogHandler will return a handler writing each request to the access log.  Each request is given an id,
+// returned in the X-Request-ID header and written by the loggers returned from WithContext within the wrapped handler
 func RequestLogHandler(h http.Handler) http.Handler {
 
 	once.Do(func() {
-		accessLog = initLogger("accessLog")
-
+		l := initLogger("accessLog")
+		configMu.Lock()
+		accessLog = l
+		configMu.Unlock()
 	})
-	return aceLoggingHandler{handler: h, accessLogger: accessLog}
+	return aceLoggingHandler{handler: h}
 }
 
-// TODO add httpstatus, add response size
 func (h aceLoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	h.handler.ServeHTTP(w, r)
+	requestID := uuid.NewRandom().String()
+	w.Header().Set(RequestIDHeader, requestID)
+	rw := NewResponseWriter(w)
 
-	latency := time.Since(start)
+	h.handler.ServeHTTP(rw, r.WithContext(WithRequestID(r.Context(), requestID)))
 
-	//		status := fn.status
-	//		if status == 0 {
-	//			status = 200
-	//		}
+	latency := time.Since(start)
 
 	fields := logrus.Fields{
-		//			"status":     status,
-		"method":   r.Method,
-		"request":  r.RequestURI,
-		"remote":   r.RemoteAddr,
-		"duration": float64(latency.Nanoseconds()) / float64(1000),
-		//			"size":       fn.size,
+		"status":     rw.Status(),
+		"method":     r.Method,
+		"request":    r.RequestURI,
+		"remote":     r.RemoteAddr,
+		"duration":   float64(latency.Nanoseconds()) / float64(1000),
+		"size":       rw.Size(),
+		"request_id": requestID,
 		"referer":    r.Referer(),
 		"user-agent": r.UserAgent(),
 	}
 
-	h.accessLogger.WithFields(fields).Info("Request Complete")
+	accessLogger().WithFields(fields).Info("Request Complete")
 
 }
@@ -0,0 +1,82 @@
+package acelog
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logrus "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordHook keeps the entries written to a logger
+type recordHook struct {
+	entries []*logrus.Entry
+}
+
+func (h *recordHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *recordHook) Fire(entry *logrus.Entry) error {
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+// recordApplicationLog will write the application log to a hook until the returned function is called
+func recordApplicationLog() (*recordHook, func()) {
+	hook := &recordHook{}
+	configMu.Lock()
+	previous := logger
+	logger = &logrus.Logger{Out: ioutil.Discard, Formatter: previous.Formatter, Hooks: make(logrus.LevelHooks), Level: logrus.DebugLevel}
+	logger.Hooks.Add(hook)
+	configMu.Unlock()
+	return hook, func() {
+		configMu.Lock()
+		logger = previous
+		configMu.Unlock()
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	assert := assert.New(t)
+	hook, restore := recordApplicationLog()
+	defer restore()
+
+	ctx := WithRequestID(context.Background(), "request-1")
+	ctx = ContextWithFields(ctx, Fields{"user": "gasket"})
+	ctx = ContextWithFields(ctx, Fields{"route": "GET /nodes", "user": "admin"})
+	WithContext(ctx).WithField("node_id", "123456789").Info("Node read")
+	WithContext(context.Background()).Warn("No request")
+
+	if assert.Len(hook.entries, 2) {
+		assert.Equal("Node read", hook.entries[0].Message)
+		assert.Equal(logrus.Fields{"request_id": "request-1", "user": "admin", "route": "GET /nodes", "node_id": "123456789"}, hook.entries[0].Data)
+		assert.Empty(hook.entries[1].Data)
+	}
+	assert.Equal("request-1", RequestID(ctx))
+	assert.Equal("", RequestID(context.Background()))
+
+	// the fields of a context are not changed by the contexts derived from it
+	parent := ContextWithFields(context.Background(), Fields{"user": "gasket"})
+	ContextWithFields(parent, Fields{"user": "admin", "route": "GET /nodes"})
+	assert.Equal(Fields{"user": "gasket"}, contextFields(parent))
+}
+
+func TestRequestLogHandlerRequestID(t *testing.T) {
+	assert := assert.New(t)
+	hook, restore := recordApplicationLog()
+	defer restore()
+
+	h := RequestLogHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WithContext(r.Context()).Info("Handling request")
+	}))
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest("GET", "/nodes", nil))
+
+	requestID := resp.Header().Get(RequestIDHeader)
+	assert.NotEmpty(requestID)
+	if assert.Len(hook.entries, 1) {
+		assert.Equal(requestID, hook.entries[0].Data["request_id"])
+	}
+}
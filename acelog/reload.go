@@ -0,0 +1,144 @@
+package acelog
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	logrus "github.com/Sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// configMu guards the application and access loggers.  A logrus.Logger is not safe to change while entries are written,
+// so a change to the configuration replaces a logger with a new one which is fully configured before it is used
+var configMu sync.RWMutex
+
+// applicationLogger will return the logger in use for the application log
+func applicationLogger() *logrus.Logger {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return logger
+}
+
+// accessLogger will return the logger in use for the access log
+func accessLogger() *logrus.Logger {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return accessLog
+}
+
+// Levels are the levels of the application and access logs.  An empty level is left unchanged by SetLevels
+type Levels struct {
+	Application string `json:"application,omitempty"`
+	Access      string `json:"access,omitempty"`
+}
+
+// GetLevels will return the current levels of the application and access logs
+func GetLevels() Levels {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	levels := Levels{Application: logger.Level.String()}
+	if accessLog != nil {
+		levels.Access = accessLog.Level.String()
+	}
+	return levels
+}
+
+// SetLevels will change the level of the application and access logs.  Neither level is changed if either is invalid
+func SetLevels(levels Levels) error {
+	var application, access logrus.Level
+	var err error
+	if levels.Application != "" {
+		if application, err = logrus.ParseLevel(levels.Application); err != nil {
+			return err
+		}
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+	if levels.Access != "" {
+		if access, err = logrus.ParseLevel(levels.Access); err != nil {
+			return err
+		}
+		if accessLog == nil {
+			return fmt.Errorf("the access log is not in use")
+		}
+	}
+	if levels.Application != "" {
+		logger = withLevel(logger, application)
+	}
+	if levels.Access != "" {
+		accessLog = withLevel(accessLog, access)
+	}
+	return nil
+}
+
+// withLevel will return a logger writing to the output of l with its format and hooks, at the level
+func withLevel(l *logrus.Logger, level logrus.Level) *logrus.Logger {
+	return &logrus.Logger{Out: l.Out, Formatter: l.Formatter, Hooks: l.Hooks, Level: level}
+}
+
+// SetOutput will write the application log to w in place of the output configured in log.toml.  A file the log wrote
+// to is closed once the new logger is in use
+func SetOutput(w io.Writer) {
+	configMu.Lock()
+	next := withLevel(logger, logger.Level)
+	next.Out = w
+	previous := logger.Out
+	logger = next
+	configMu.Unlock()
+	closeReplaced(previous, w)
+}
+
+// Reload will read log.toml again and reconfigure the level, format and output of the application and access logs.
+// The logs keep their configuration if the file cannot be read
+func Reload() error {
+	v, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	previous, previousAccess := logger, accessLog
+	logger = reconfigure(logger, setConfiguration(v, "applicationLog"))
+	if accessLog != nil {
+		accessLog = reconfigure(accessLog, setConfiguration(v, "accessLog"))
+	}
+	next, nextAccess := logger, accessLog
+	configMu.Unlock()
+
+	closeReplaced(previous.Out, next.Out)
+	if previousAccess != nil {
+		closeReplaced(previousAccess.Out, nextAccess.Out)
+	}
+	return nil
+}
+
+// reconfigure will return a logger with the configuration and the hooks of l.  When the configuration names the file
+// l already writes to, with the same rotation, the writer of l is kept so that entries written by l while the logger
+// is replaced share the writer and its lock with the new logger
+func reconfigure(l *logrus.Logger, config *LogConfig) *logrus.Logger {
+	next := logrus.New()
+	next.Hooks = l.Hooks
+	Configure(next, config)
+	if file, ok := l.Out.(*lumberjack.Logger); ok {
+		if nextFile, ok := next.Out.(*lumberjack.Logger); ok && sameFile(file, nextFile) {
+			next.Out = file
+		}
+	}
+	return next
+}
+
+// sameFile will return true when both writers write to the same file with the same rotation
+func sameFile(a *lumberjack.Logger, b *lumberjack.Logger) bool {
+	return a.Filename == b.Filename && a.MaxSize == b.MaxSize && a.MaxBackups == b.MaxBackups &&
+		a.MaxAge == b.MaxAge && a.LocalTime == b.LocalTime
+}
+
+// closeReplaced will close the file previous wrote to, unless it is still in use as next.  It is called once the
+// logger writing to next is installed, so that new entries are no longer written to previous
+func closeReplaced(previous io.Writer, next io.Writer) {
+	if file, ok := previous.(*lumberjack.Logger); ok && previous != next {
+		file.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package acelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	logrus "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLevelsWhileLogging(t *testing.T) {
+	assert := assert.New(t)
+	initial := GetLevels()
+	defer SetLevels(Levels{Application: initial.Application})
+
+	configMu.Lock()
+	previous := logger
+	logger = withLevel(logger, logger.Level)
+	logger.Out = ioutil.Discard
+	configMu.Unlock()
+	defer func() {
+		configMu.Lock()
+		logger = previous
+		configMu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				WithFields(Fields{"j": j}).Info("logging while the level changes")
+				Debug("logging while the level changes")
+			}
+		}()
+	}
+	for _, level := range []string{"debug", "warning", "info", "error"} {
+		assert.NoError(SetLevels(Levels{Application: level}))
+	}
+	wg.Wait()
+	assert.Equal("error", GetLevels().Application)
+
+	assert.Error(SetLevels(Levels{Application: "verbose"}))
+	assert.Equal("error", GetLevels().Application)
+}
+
+func TestReconfigureOutput(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "gasket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := logrus.New()
+	Configure(l, &LogConfig{format: "text", output: "file", level: "info", filename: filepath.Join(dir, "application.log")})
+	l.Info("before the reload")
+
+	// the file is kept when only the level and format change, so that l and next share its writer
+	next := reconfigure(l, &LogConfig{format: "json", output: "file", level: "error", filename: filepath.Join(dir, "application.log")})
+	assert.True(l.Out == next.Out)
+	assert.IsType(&logrus.JSONFormatter{}, next.Formatter)
+	assert.Equal(logrus.ErrorLevel, next.Level)
+	closeReplaced(l.Out, next.Out)
+	l.Info("written by an entry of the previous logger")
+	next.Error("after the reload")
+	closeReplaced(next.Out, nil)
+
+	written, err := ioutil.ReadFile(filepath.Join(dir, "application.log"))
+	assert.NoError(err)
+	for _, message := range []string{"before the reload", "written by an entry of the previous logger", "after the reload"} {
+		assert.Contains(string(written), message)
+	}
+
+	// a new writer is opened for another file or rotation
+	for _, config := range []*LogConfig{
+		{output: "file", filename: filepath.Join(dir, "other.log")},
+		{output: "file", filename: filepath.Join(dir, "application.log"), maxsize: 10},
+		{output: "stdout"},
+	} {
+		assert.False(l.Out == reconfigure(l, config).Out, config.output+" "+config.filename)
+	}
+}
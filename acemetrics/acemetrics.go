@@ -0,0 +1,156 @@
+package acemetrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/gkontos/gasket/acelog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gasket"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	storeTransactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "transactions_total",
+		Help:      "Number of transactions applied to the store by result.",
+	}, []string{"result"})
+
+	storeTransactionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "transaction_duration_seconds",
+		Help:      "Latency of transactions applied to the store by result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	storeTransactionQuads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "transaction_quads_total",
+		Help:      "Number of quads added or removed by transactions applied to the store.",
+	}, []string{"action"})
+
+	storeScans = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "scans_total",
+		Help:      "Number of iterator scans of the store by scan.",
+	}, []string{"scan"})
+
+	storeScanDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "scan_duration_seconds",
+		Help:      "Latency of iterator scans of the store by scan.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"scan"})
+
+	storeScanQuads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "scanned_quads_total",
+		Help:      "Number of quads read by iterator scans of the store by scan.",
+	}, []string{"scan"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpDuration,
+		storeTransactions, storeTransactionDuration, storeTransactionQuads,
+		storeScans, storeScanDuration, storeScanQuads)
+}
+
+// Handler will return a handler serving the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterStore will expose the number of quads within the store as the gasket_store_quads gauge.  The store is sized
+// each time the metrics are read
+func RegisterStore(store *cayley.Handle) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "quads",
+		Help:      "Number of quads within the store.",
+	}, func() float64 {
+		return float64(store.Size())
+	}))
+}
+
+// InstrumentHandler will return a handler which counts and times the requests to h under the route.  route should be the
+// path template of the handler rather than the request path, so that the number of series stays bounded
+func InstrumentHandler(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := acelog.NewResponseWriter(w)
+		h.ServeHTTP(rw, r)
+
+		labels := prometheus.Labels{"route": route, "method": r.Method, "status": strconv.Itoa(rw.Status())}
+		httpRequests.With(labels).Inc()
+		httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveTransaction will record a transaction applied to the store, started at start, with err the result of the transaction
+func ObserveTransaction(tx *graph.Transaction, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	storeTransactions.WithLabelValues(result).Inc()
+	storeTransactionDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	for i := range tx.Deltas {
+		switch tx.Deltas[i].Action {
+		case graph.Add:
+			storeTransactionQuads.WithLabelValues("add").Inc()
+		case graph.Delete:
+			storeTransactionQuads.WithLabelValues("remove").Inc()
+		}
+	}
+}
+
+// Scan records an iterator scan of the store.  Quad is called for each quad read, and Done once the scan is complete
+type Scan struct {
+	name  string
+	start time.Time
+	quads int
+}
+
+// StartScan will return a Scan recorded under the name
+func StartScan(name string) *Scan {
+	return &Scan{name: name, start: time.Now()}
+}
+
+// Quad will count a quad read by the scan
+func (s *Scan) Quad() {
+	s.quads++
+}
+
+// Done will record the scan
+func (s *Scan) Done() {
+	storeScans.WithLabelValues(s.name).Inc()
+	storeScanDuration.WithLabelValues(s.name).Observe(time.Since(s.start).Seconds())
+	storeScanQuads.WithLabelValues(s.name).Add(float64(s.quads))
+}
@@ -1,11 +1,25 @@
 package aceservice
 
-import "github.com/cayleygraph/cayley"
+import (
+	"fmt"
+	"strings"
 
-var store *cayley.Handle
+	"github.com/cayleygraph/cayley"
+)
 
-func SetStore(s *cayley.Handle) {
-	store = s
+// QuadService provides quad level access to a graph store.  It is embedded within the node, relation and metadata services
+type QuadService struct {
+	store *cayley.Handle
+}
+
+// NewQuadService will return a QuadService for the given store
+func NewQuadService(store *cayley.Handle) *QuadService {
+	return &QuadService{store: store}
+}
+
+// Store will return the underlying graph store
+func (s *QuadService) Store() *cayley.Handle {
+	return s.store
 }
 
 type DataStoreError struct {
@@ -19,3 +33,42 @@ func (e *DataStoreError) Error() string {
 	}
 	return e.Err.Error()
 }
+
+// ConflictError indicates that a change cannot be made in the current state of the store
+type ConflictError struct {
+	Err     error
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Message != "" {
+		return e.Message + " " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// MissingReferenceError indicates that a node or relation referenced by a change does not exist.  Missing lists the ids which were not found
+type MissingReferenceError struct {
+	Err     error
+	Message string
+	Missing []string
+}
+
+func (e *MissingReferenceError) Error() string {
+	if e.Message != "" {
+		return e.Message + " " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// missingReferences will return a MissingReferenceError for the missing ids, or nil if none are missing
+func missingReferences(kind string, missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return &MissingReferenceError{
+		Message: "Unable to save data",
+		Err:     fmt.Errorf("%s not found : %s", kind, strings.Join(missing, ", ")),
+		Missing: missing,
+	}
+}
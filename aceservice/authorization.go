@@ -0,0 +1,162 @@
+package aceservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cayleygraph/cayley/quad"
+	log "github.com/gkontos/gasket/acelog"
+)
+
+// Permission is an action a role may be granted on the quads of a label
+type Permission string
+
+const (
+	PermissionRead   Permission = "read"
+	PermissionWrite  Permission = "write"
+	PermissionDelete Permission = "delete"
+)
+
+// Endpoint groups a role may be granted.  Each group is served by the endpoints under the path of the same name
+const (
+	GroupNodes     = "nodes"
+	GroupRelations = "relations"
+	GroupMetadata  = "metadata"
+	GroupPaths     = "paths"
+	GroupTypes     = "types"
+	GroupImport    = "import"
+	GroupExport    = "export"
+	GroupAdmin     = "admin"
+)
+
+// Wildcard matches every label or endpoint group of a role
+const Wildcard = "*"
+
+// Role grants permissions on the quads of each listed label within the listed endpoint groups.  Quads without
+// a label are listed as ""
+type Role struct {
+	Groups []string `mapstructure:"groups"`
+	Read   []string `mapstructure:"read"`
+	Write  []string `mapstructure:"write"`
+	Delete []string `mapstructure:"delete"`
+}
+
+// labels will return the labels the role grants the permission on
+func (r Role) labels(permission Permission) []string {
+	switch permission {
+	case PermissionRead:
+		return r.Read
+	case PermissionWrite:
+		return r.Write
+	case PermissionDelete:
+		return r.Delete
+	}
+	return nil
+}
+
+// Principal is the caller of a service along with the roles granted to it
+type Principal struct {
+	Subject string
+	Roles   []Role
+}
+
+// Allows will return true if a role of the principal grants the permission on the label within the endpoint group
+func (p *Principal) Allows(group string, permission Permission, label string) bool {
+	for _, role := range p.Roles {
+		if contains(role.Groups, group) && contains(role.labels(permission), label) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAny will return true if a role of the principal grants the permission on any label within the endpoint group
+func (p *Principal) AllowsAny(group string, permission Permission) bool {
+	for _, role := range p.Roles {
+		if contains(role.Groups, group) && len(role.labels(permission)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// contains will return true if values holds the value or the wildcard
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == Wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationError indicates that the caller is not permitted to make a request
+type AuthorizationError struct {
+	Err     error
+	Message string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Message != "" {
+		return e.Message + " " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+type principalKey struct{}
+
+// WithPrincipal will return a copy of ctx carrying the principal.  Service calls made with the context are limited
+// to the permissions of the principal's roles
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext will return the principal carried by ctx
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// labelText will return a quad label as it is listed within a role
+func labelText(label quad.Value) string {
+	if label == nil {
+		return ""
+	}
+	return literalText(label)
+}
+
+// permitted will return true if the caller may use the permission on the label within the group.  Calls without
+// a principal, such as those made from the command line, are not restricted
+func permitted(ctx context.Context, group string, permission Permission, label quad.Value) bool {
+	p, ok := PrincipalFromContext(ctx)
+	return !ok || p.Allows(group, permission, labelText(label))
+}
+
+// authorize will return an AuthorizationError unless the caller may use the permission on every label within the group
+func authorize(ctx context.Context, group string, permission Permission, labels ...quad.Value) error {
+	for _, label := range labels {
+		if !permitted(ctx, group, permission, label) {
+			err := &AuthorizationError{
+				Message: "Forbidden",
+				Err:     fmt.Errorf("%s is not permitted on label %q of %s", permission, labelText(label), group),
+			}
+			log.WithContext(ctx).Warn(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthorizeGroup will return an AuthorizationError unless the caller may use the permission on some label within the group.
+// Endpoints which are not backed by a service, such as those of the admin group, are authorized with it
+func AuthorizeGroup(ctx context.Context, group string, permission Permission) error {
+	if p, ok := PrincipalFromContext(ctx); ok && !p.AllowsAny(group, permission) {
+		err := &AuthorizationError{
+			Message: "Forbidden",
+			Err:     fmt.Errorf("%s is not permitted on %s", permission, group),
+		}
+		log.WithContext(ctx).Warn(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package aceservice
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/acemetrics"
+	"github.com/gkontos/gasket/model"
+)
+
+// ExportService writes the contents of a graph store in N-Quads, JSON-LD or gasket JSON
+type ExportService struct {
+	*QuadService
+	relations *RelationService
+	metadata  *MetadataService
+}
+
+// NewExportService will return an ExportService for the given store
+func NewExportService(store *cayley.Handle) *ExportService {
+	return &ExportService{
+		QuadService: NewQuadService(store),
+		relations:   NewRelationService(store),
+		metadata:    NewMetadataService(store),
+	}
+}
+
+// labelValue will return the quad value for a label parameter, or nil for all labels
+func labelValue(label string) quad.Value {
+	if label == "" {
+		return nil
+	}
+	return quad.String(label)
+}
+
+// sameLabel will return true when no label is requested or the value matches the label
+func sameLabel(value quad.Value, label quad.Value) bool {
+	return label == nil || quad.StringOf(value) == quad.StringOf(label)
+}
+
+// eachQuad will call fn for every quad in the store with the label, or every quad when label is nil.  Quads with
+// a label the caller may not read are skipped
+func (s *ExportService) eachQuad(ctx context.Context, label quad.Value, fn func(q quad.Quad) error) error {
+	if err := AuthorizeGroup(ctx, GroupExport, PermissionRead); err != nil {
+		return err
+	}
+	var it graph.Iterator
+	if label == nil {
+		it = s.store.QuadsAllIterator()
+	} else {
+		it = s.store.QuadIterator(quad.Label, s.store.ValueOf(label))
+	}
+	defer it.Close()
+	scan := acemetrics.StartScan("export")
+	defer scan.Done()
+	for it.Next() {
+		q := s.store.Quad(it.Result())
+		scan.Quad()
+		if !permitted(ctx, GroupExport, PermissionRead, q.Label) {
+			continue
+		}
+		if err := fn(q); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return &DataStoreError{Message: "Error reading quads", Err: err}
+	}
+	return nil
+}
+
+// exportValue will return a value which can be written as N-Quads.  Properties received as JSON are stored as raw text,
+// and relation id subjects are JSON documents; both are written as strings
+func exportValue(v quad.Value) quad.Value {
+	switch value := v.(type) {
+	case quad.Raw:
+		return quad.String(value)
+	case quad.IRI:
+		if strings.ContainsAny(string(value), "<>\" {}") {
+			return quad.String(value)
+		}
+	}
+	return v
+}
+
+// exportQuad will return the quad with each value converted by exportValue
+func exportQuad(q quad.Quad) quad.Quad {
+	q.Subject = exportValue(q.Subject)
+	q.Predicate = exportValue(q.Predicate)
+	q.Object = exportValue(q.Object)
+	if q.Label != nil {
+		q.Label = exportValue(q.Label)
+	}
+	return q
+}
+
+// ExportNQuads will write every quad with the label to w, one quad per line.  All quads are written when label is empty
+func (s *ExportService) ExportNQuads(ctx context.Context, w io.Writer, label string) error {
+	return s.eachQuad(ctx, labelValue(label), func(q quad.Quad) error {
+		_, err := io.WriteString(w, exportQuad(q).NQuad()+"\n")
+		return err
+	})
+}
+
+// ExportJSONLD will write every quad with the label to w as a JSON-LD document.  Quads are grouped by subject
+// within a named graph for each label.  All quads are written when label is empty.
+// The subjects of each graph are found first, then the quads of each subject are read and written in turn
+func (s *ExportService) ExportJSONLD(ctx context.Context, w io.Writer, label string) error {
+	graphs := make(map[string]map[string][]quad.Value)
+	err := s.eachQuad(ctx, labelValue(label), func(q quad.Quad) error {
+		graphID := jsonLDGraph(q.Label)
+		if graphs[graphID] == nil {
+			graphs[graphID] = make(map[string][]quad.Value)
+		}
+		subjectID := jsonLDID(exportValue(q.Subject))
+		for _, subject := range graphs[graphID][subjectID] {
+			if subject == q.Subject {
+				return nil
+			}
+		}
+		graphs[graphID][subjectID] = append(graphs[graphID][subjectID], q.Subject)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the document is written one subject at a time.  Named graphs are written as {"@graph":[subjects],"@id":label}
+	if _, err = io.WriteString(w, "["); err != nil {
+		return err
+	}
+	separator := ""
+	for _, graphID := range sortedKeys(graphs) {
+		suffix := ""
+		if graphID != "" {
+			id, err := json.Marshal(graphID)
+			if err != nil {
+				return err
+			}
+			if _, err = io.WriteString(w, separator+`{"@graph":[`); err != nil {
+				return err
+			}
+			separator, suffix = "", `],"@id":`+string(id)+"}"
+		}
+		for _, subjectID := range sortedSubjects(graphs[graphID]) {
+			subject, err := s.jsonLDSubject(ctx, graphID, subjectID, graphs[graphID][subjectID])
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(subject)
+			if err != nil {
+				return err
+			}
+			if _, err = io.WriteString(w, separator+string(b)); err != nil {
+				return err
+			}
+			separator = ","
+		}
+		if suffix != "" {
+			if _, err = io.WriteString(w, suffix); err != nil {
+				return err
+			}
+			separator = ","
+		}
+	}
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
+
+// jsonLDSubject will return the JSON-LD object for a subject, holding the quads of the subject within the graph
+func (s *ExportService) jsonLDSubject(ctx context.Context, graphID string, subjectID string, values []quad.Value) (map[string]interface{}, error) {
+	subject := map[string]interface{}{"@id": subjectID}
+	for _, value := range values {
+		it := s.store.QuadIterator(quad.Subject, s.store.ValueOf(value))
+		for it.Next() {
+			q := s.store.Quad(it.Result())
+			if jsonLDGraph(q.Label) != graphID || !permitted(ctx, GroupExport, PermissionRead, q.Label) {
+				continue
+			}
+			q = exportQuad(q)
+			predicate := jsonLDID(q.Predicate)
+			objects, _ := subject[predicate].([]interface{})
+			subject[predicate] = append(objects, jsonLDObject(q.Object))
+		}
+		err := it.Err()
+		it.Close()
+		if err != nil {
+			return nil, &DataStoreError{Message: "Error reading quads", Err: err}
+		}
+	}
+	return subject, nil
+}
+
+// jsonLDGraph will return the identifier of the named graph for a label, or "" for quads without a label
+func jsonLDGraph(label quad.Value) string {
+	if label == nil {
+		return ""
+	}
+	return jsonLDID(exportValue(label))
+}
+
+// jsonLDID will return the identifier used for a subject, predicate or graph in JSON-LD
+func jsonLDID(v quad.Value) string {
+	switch value := v.(type) {
+	case quad.IRI:
+		return string(value)
+	case quad.BNode:
+		return value.String()
+	}
+	if s, ok := quad.NativeOf(v).(string); ok {
+		return s
+	}
+	return quad.StringOf(v)
+}
+
+// jsonLDObject will return the JSON-LD representation of an object value
+func jsonLDObject(v quad.Value) map[string]interface{} {
+	switch value := v.(type) {
+	case quad.IRI, quad.BNode:
+		return map[string]interface{}{"@id": jsonLDID(value)}
+	case quad.TypedString:
+		return map[string]interface{}{"@value": string(value.Value), "@type": string(value.Type)}
+	case quad.LangString:
+		return map[string]interface{}{"@value": string(value.Value), "@language": value.Lang}
+	case quad.Time:
+		return map[string]interface{}{"@value": value.Native()}
+	}
+	return map[string]interface{}{"@value": quad.NativeOf(v)}
+}
+
+func sortedKeys(graphs map[string]map[string][]quad.Value) []string {
+	var keys []string
+	for key := range graphs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSubjects(subjects map[string][]quad.Value) []string {
+	var keys []string
+	for key := range subjects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ExportGraph will write the nodes, relations and metadata with the label to w as a gasket JSON document.
+// The objects are written in the format accepted by the node, relation and metadata endpoints.  All objects are written when label is empty.
+// Nodes and relations with a label the caller may not read are left out, along with the metadata of those relations
+// Relations which have no relation id, such as those imported as N-Quads, are written without an id
+func (s *ExportService) ExportGraph(ctx context.Context, w io.Writer, label string) error {
+	if err := AuthorizeGroup(ctx, GroupExport, PermissionRead); err != nil {
+		return err
+	}
+	labelFilter := labelValue(label)
+	// objects are checked against the export group rather than the groups of their endpoints
+	exported := func(value quad.Value) bool {
+		return sameLabel(value, labelFilter) && permitted(ctx, GroupExport, PermissionRead, value)
+	}
+	enc := json.NewEncoder(w)
+
+	// the document is written one object at a time
+	section := func(name string, first bool, write func(item func(v interface{}) error) error) error {
+		prefix := `,"` + name + `":[`
+		if first {
+			prefix = `{"` + name + `":[`
+		}
+		if _, err := io.WriteString(w, prefix); err != nil {
+			return err
+		}
+		count := 0
+		err := write(func(v interface{}) error {
+			if count > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			count++
+			return enc.Encode(v)
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "]")
+		return err
+	}
+
+	err := section("nodes", true, func(item func(v interface{}) error) error {
+		// the ids are read first, then each node is read and written in turn
+		found := make(map[string]bool)
+		var nodeIDs []string
+		s.eachWithPredicate(model.NamePredicate, "export", func(q quad.Quad) {
+			if nodeID, ok := q.Subject.(quad.IRI); ok && !found[string(nodeID)] {
+				found[string(nodeID)] = true
+				nodeIDs = append(nodeIDs, string(nodeID))
+			}
+		})
+		sort.Strings(nodeIDs)
+		for _, nodeID := range nodeIDs {
+			node, err := QuadListToNode(s.GetQuadsBySubject(nodeID))
+			if err != nil {
+				return err
+			}
+			if !exported(node.Label) {
+				continue
+			}
+			for key, value := range node.Properties {
+				// relations are exported separately
+				if _, isRelation := value.(quad.IRI); isRelation {
+					delete(node.Properties, key)
+				}
+			}
+			if err := item(node); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var relationIDs []string
+	err = section("relations", false, func(item func(v interface{}) error) error {
+		// every quad linking two nodes is a relation, which is written with an empty id when it has no relation id
+		index := s.relations.RelationIDIndex()
+		relations := make(map[string]model.Relation)
+		var keys []string
+		scan := acemetrics.StartScan("export")
+		it := s.store.QuadsAllIterator()
+		for it.Next() {
+			q := s.store.Quad(it.Result())
+			scan.Quad()
+			if !isRelationQuad(q) || !exported(q.Label) {
+				continue
+			}
+			key := quadKey(q)
+			relations[key] = QuadToRelation(index[key], q)
+			keys = append(keys, key)
+		}
+		err := it.Err()
+		it.Close()
+		scan.Done()
+		if err != nil {
+			return &DataStoreError{Message: "Error reading relations", Err: err}
+		}
+
+		sort.Strings(keys)
+		for _, key := range keys {
+			relation := relations[key]
+			if relation.ID != "" {
+				relationIDs = append(relationIDs, string(relation.ID))
+			}
+			if err := item(relation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = section("metadata", false, func(item func(v interface{}) error) error {
+		for _, relationID := range relationIDs {
+			for _, metadataID := range s.metadata.MetadataIDsForRelation(relationID) {
+				metadata, err := QuadListToMetadata(s.metadata.GetMetadataQuadsByID(string(metadataID)))
+				if err != nil {
+					return err
+				}
+				if err := item(metadata); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "}\n")
+	return err
+}
@@ -0,0 +1,98 @@
+package aceservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportJSONLD(t *testing.T) {
+	assert := assert.New(t)
+	s := NewExportService(makeTestStore(t))
+
+	var buf bytes.Buffer
+	assert.NoError(s.ExportJSONLD(context.Background(), &buf, ""))
+	var document []map[string]interface{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &document))
+
+	// the unlabelled subjects, then the named graph of the test label
+	if assert.Len(document, 7) {
+		assert.Equal("123456789", document[0]["@id"])
+		assert.Len(document[0]["similarto"], 1)
+		assert.Len(document[2]["hasMetaId"], 2, "the quads of a subject are written together")
+		assert.Equal("test", document[6]["@id"])
+		subjects, _ := document[6]["@graph"].([]interface{})
+		if assert.Len(subjects, 6) {
+			node, _ := subjects[0].(map[string]interface{})
+			assert.Equal("123456789", node["@id"])
+			assert.Equal([]interface{}{map[string]interface{}{"@value": "Shimmering Substance"}}, node["schema:name"])
+		}
+	}
+
+	buf.Reset()
+	assert.NoError(s.ExportJSONLD(context.Background(), &buf, "test"))
+	document = nil
+	assert.NoError(json.Unmarshal(buf.Bytes(), &document))
+	if assert.Len(document, 1) {
+		assert.Equal("test", document[0]["@id"])
+	}
+
+	// quads with a label the caller may not read are left out
+	unlabelled := &Principal{Subject: "gasket", Roles: []Role{{Groups: []string{GroupExport}, Read: []string{""}}}}
+	buf.Reset()
+	assert.NoError(s.ExportJSONLD(WithPrincipal(context.Background(), unlabelled), &buf, ""))
+	document = nil
+	assert.NoError(json.Unmarshal(buf.Bytes(), &document))
+	assert.Len(document, 6)
+}
+
+func TestExportGraphRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	// a relation imported as N-Quads has no relation id
+	source, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := NewImportService(source).ImportNQuads(ctx, strings.NewReader(
+		"<a> <schema:name> \"A\" .\n<b> <schema:name> \"B\" .\n<a> <acedfs:input> <b> .\n"), 0, nil)
+	assert.NoError(err)
+	assert.Equal(3, summary.Imported)
+
+	var buf bytes.Buffer
+	assert.NoError(NewExportService(source).ExportGraph(ctx, &buf, ""))
+	var doc model.GraphDocument
+	assert.NoError(json.Unmarshal(buf.Bytes(), &doc))
+	assert.Len(doc.Nodes, 2)
+	if assert.Len(doc.Relations, 1) {
+		assert.Equal(model.Relation{SourceID: "a", Type: "acedfs:input", TargetID: "b"}, doc.Relations[0])
+	}
+
+	// the nodes are given new ids, which the relation is linked to
+	target, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err = NewImportService(target).ImportGraph(ctx, &buf)
+	assert.NoError(err)
+	assert.Equal(3, summary.Imported)
+	assert.Zero(summary.Invalid)
+
+	nodes, _, err := NewNodeService(target).ListNodes(ctx, nil, "", 0)
+	assert.NoError(err)
+	ids := make(map[string]quad.IRI)
+	for _, node := range nodes {
+		ids[node.Name] = node.ID
+	}
+	if assert.Len(ids, 2) {
+		assert.True(NewQuadService(target).QuadExists(quad.Make(ids["A"], quad.IRI("acedfs:input"), ids["B"], nil)))
+	}
+}
@@ -0,0 +1,41 @@
+package aceservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/cayleygraph/cayley"
+)
+
+// HealthService checks that a graph store can be queried
+type HealthService struct {
+	store *cayley.Handle
+}
+
+// NewHealthService will return a HealthService for the given store
+func NewHealthService(store *cayley.Handle) *HealthService {
+	return &HealthService{store: store}
+}
+
+// Ping will read a single quad from the store and return the time taken.  A DataStoreError is returned when the
+// read fails or does not complete before ctx is done
+func (s *HealthService) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		it := s.store.QuadsAllIterator()
+		defer it.Close()
+		it.Next()
+		done <- it.Err()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return time.Since(start), &DataStoreError{Message: "Unable to query store", Err: err}
+		}
+		return time.Since(start), nil
+	case <-ctx.Done():
+		return time.Since(start), &DataStoreError{Message: "Unable to query store", Err: ctx.Err()}
+	}
+}
@@ -0,0 +1,209 @@
+package aceservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/quad/cquads"
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/model"
+)
+
+// DefaultBatchSize is the number of quads written in each transaction of an import
+const DefaultBatchSize = 10000
+
+// maxImportErrors is the number of invalid line messages kept in an ImportSummary
+const maxImportErrors = 10
+
+// ImportProgress is called with the running summary after each batch of an import is written
+type ImportProgress func(summary model.ImportSummary)
+
+// ImportService loads N-Quads or gasket JSON documents into a graph store
+type ImportService struct {
+	*QuadService
+	nodes     *NodeService
+	relations *RelationService
+	metadata  *MetadataService
+}
+
+// NewImportService will return an ImportService for the given store
+func NewImportService(store *cayley.Handle) *ImportService {
+	return &ImportService{
+		QuadService: NewQuadService(store),
+		nodes:       NewNodeService(store),
+		relations:   NewRelationService(store),
+		metadata:    NewMetadataService(store),
+	}
+}
+
+// ImportNQuads will read N-Quads from r and write them to the store in transactions of batchSize quads.
+// Quads already in the store are skipped and lines which cannot be parsed are counted as invalid.
+// The caller must be permitted to write every label imported.  Batches written before an error is returned remain in the store
+func (s *ImportService) ImportNQuads(ctx context.Context, r io.Reader, batchSize int, progress ImportProgress) (model.ImportSummary, error) {
+	var summary model.ImportSummary
+	if err := AuthorizeGroup(ctx, GroupImport, PermissionWrite); err != nil {
+		return summary, err
+	}
+	logger := log.WithContext(ctx)
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+
+	source := &readErrorRecorder{r: r}
+	dec := cquads.NewDecoder(source)
+	batch := make(map[string]quad.Quad)
+	var order []string
+
+	flush := func() error {
+		if len(order) == 0 {
+			return nil
+		}
+		tx := cayley.NewTransaction()
+		for _, key := range order {
+			tx.AddQuad(batch[key])
+		}
+		if err := s.applyTransaction(tx); err != nil {
+			return &DataStoreError{Message: "Error saving batch", Err: err}
+		}
+		summary.Imported += len(order)
+		summary.Batches++
+		logger.WithFields(log.Fields{"batch": summary.Batches, "imported": summary.Imported}).Debug("Import batch written")
+		batch = make(map[string]quad.Quad)
+		order = order[:0]
+		if progress != nil {
+			progress(summary)
+		}
+		return nil
+	}
+
+	for {
+		q, err := dec.Unmarshal()
+		if err == io.EOF {
+			break
+		} else if source.err != nil {
+			return summary, source.err
+		} else if err != nil {
+			summary.Invalid++
+			if len(summary.Errors) < maxImportErrors {
+				summary.Errors = append(summary.Errors, err.Error())
+			}
+			logger.WithField("invalid", summary.Invalid).Debug("Skipping invalid line : ", err)
+			continue
+		}
+
+		if err := authorize(ctx, GroupImport, PermissionWrite, q.Label); err != nil {
+			return summary, err
+		}
+		key := quadKey(q)
+		if _, ok := batch[key]; ok || s.QuadExists(q) {
+			summary.Skipped++
+			continue
+		}
+		batch[key] = q
+		order = append(order, key)
+
+		if len(order) >= batchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	return summary, flush()
+}
+
+// ImportGraph will read a gasket JSON document from r, as written by ExportGraph, and add its nodes, relations and metadata
+// to the store.  Each object is given a new id, and the relations and metadata are linked to the new ids of the objects
+// they reference.  Objects which fail their checks are counted as invalid; Imported counts the objects added
+func (s *ImportService) ImportGraph(ctx context.Context, r io.Reader) (model.ImportSummary, error) {
+	var summary model.ImportSummary
+	if err := AuthorizeGroup(ctx, GroupImport, PermissionWrite); err != nil {
+		return summary, err
+	}
+	var doc model.GraphDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return summary, err
+	}
+
+	// invalid will count an object which could not be added, or return err if the import cannot continue
+	invalid := func(kind string, ID quad.IRI, err error) error {
+		switch err.(type) {
+		case *DataStoreError, *AuthorizationError:
+			return err
+		}
+		summary.Invalid++
+		if len(summary.Errors) < maxImportErrors {
+			summary.Errors = append(summary.Errors, kind+" "+string(ID)+" : "+err.Error())
+		}
+		return nil
+	}
+	ids := make(map[quad.IRI]quad.IRI)
+	newID := func(ID quad.IRI) quad.IRI {
+		if mapped, ok := ids[ID]; ok {
+			return mapped
+		}
+		// references to objects which are not in the document are kept, they may already be in the store
+		return ID
+	}
+
+	for _, node := range doc.Nodes {
+		quads, err := s.nodes.AddNode(ctx, node)
+		if err == nil && len(quads) == 0 {
+			err = fmt.Errorf("node has no properties")
+		}
+		if err != nil {
+			if err = invalid("node", node.ID, err); err != nil {
+				return summary, err
+			}
+			continue
+		}
+		ids[node.ID] = quads[0].Subject.(quad.IRI)
+		summary.Imported++
+	}
+	for _, relation := range doc.Relations {
+		ID := relation.ID
+		relation.SourceID = newID(relation.SourceID)
+		relation.TargetID = newID(relation.TargetID)
+		if err := s.relations.AddQuadRelationship(ctx, &relation, true); err != nil {
+			if err = invalid("relation", ID, err); err != nil {
+				return summary, err
+			}
+			continue
+		}
+		// relations exported without a relation id are not referenced by metadata
+		if ID != "" {
+			ids[ID] = relation.ID
+		}
+		summary.Imported++
+	}
+	for _, metadata := range doc.Metadata {
+		ID := metadata.ID
+		metadata.RelationID = newID(metadata.RelationID)
+		if err := s.metadata.AddMetadata(ctx, &metadata, true); err != nil {
+			if err = invalid("metadata", ID, err); err != nil {
+				return summary, err
+			}
+			continue
+		}
+		summary.Imported++
+	}
+	log.WithContext(ctx).WithFields(log.Fields{"imported": summary.Imported, "invalid": summary.Invalid}).Info("Graph imported")
+	return summary, nil
+}
+
+// readErrorRecorder keeps the last error from the underlying reader so read failures can be told apart from parse errors
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (rr *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if err != nil && err != io.EOF {
+		rr.err = err
+	}
+	return n, err
+}
@@ -1,6 +1,7 @@
 package aceservice
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/cayleygraph/cayley"
@@ -11,32 +12,54 @@ import (
 	"github.com/pborman/uuid"
 )
 
-// AddMetadata will save a metadata relation quad and the property quads to the underlying store
-func AddMetadata(metadata *model.Metadata) error {
+// MetadataService reads and writes relation metadata within a graph store
+type MetadataService struct {
+	*QuadService
+}
+
+// NewMetadataService will return a MetadataService for the given store
+func NewMetadataService(store *cayley.Handle) *MetadataService {
+	return &MetadataService{QuadService: NewQuadService(store)}
+}
+
+// AddMetadata will save a metadata relation quad and the property quads to the underlying store.  When checkReferences is set
+// a MissingReferenceError is returned if the relation does not exist; bulk loads may skip the check.
+// Metadata is authorized against the label of its relation
+func (s *MetadataService) AddMetadata(ctx context.Context, metadata *model.Metadata, checkReferences bool) error {
+	label, found := s.relationLabel(string(metadata.RelationID))
+	if checkReferences && !found {
+		return missingReferences("relations", []string{string(metadata.RelationID)})
+	}
+	if err := authorize(ctx, GroupMetadata, PermissionWrite, label); err != nil {
+		return err
+	}
 	metadata.ID = quad.IRI(uuid.NewUUID().String())
 	metadataIDQuad := quad.Make(metadata.RelationID,
 		model.MetaidPredicate,
 		metadata.ID,
 		"")
 
-	metadataQuads := getMetadataPropertiesAsQuads(*metadata)
+	metadataQuads := getMetadataPropertiesAsQuads(ctx, *metadata)
 	metadataQuads = append(metadataQuads, metadataIDQuad)
 
 	tx := cayley.NewTransaction()
 	for _, q := range metadataQuads {
 		tx.AddQuad(q)
 	}
-	err := store.ApplyTransaction(tx)
+	err := s.applyTransaction(tx)
+	if err == nil {
+		log.WithContext(ctx).WithFields(log.Fields{"metadata_id": string(metadata.ID), "relation_id": string(metadata.RelationID)}).Debug("Metadata added")
+	}
 	return err
 }
 
 // getMetadataPropertiesAsQuads will return the properties map as a list of quads
-func getMetadataPropertiesAsQuads(metadata model.Metadata) []quad.Quad {
+func getMetadataPropertiesAsQuads(ctx context.Context, metadata model.Metadata) []quad.Quad {
 	var metadataQuads []quad.Quad
 	for key, value := range metadata.Properties {
 		objectValue, ok := quad.AsValue(value)
 		if !ok {
-			log.Error("Unable to parse value : ", value)
+			log.WithContext(ctx).WithFields(log.Fields{"metadata_id": string(metadata.ID), "property": key}).Error("Unable to parse value : ", value)
 		}
 		q := quad.Make(
 			metadata.ID,
@@ -50,55 +73,85 @@ func getMetadataPropertiesAsQuads(metadata model.Metadata) []quad.Quad {
 	return metadataQuads
 }
 
+// metadataLabel will return the label of the relation the metadata quads are attached to
+func (s *MetadataService) metadataLabel(quadList []quad.Quad) quad.Value {
+	for _, q := range quadList {
+		if q.Predicate == model.MetaidPredicate {
+			label, _ := s.relationLabel(literalText(q.Subject))
+			return label
+		}
+	}
+	return nil
+}
+
+// GetMetadata will return the identity quad and property quads of the metadata with the given id
+func (s *MetadataService) GetMetadata(ctx context.Context, metadataID string) ([]quad.Quad, error) {
+	quadList := s.GetMetadataQuadsByID(metadataID)
+	if len(quadList) == 0 {
+		return quadList, nil
+	}
+	return quadList, authorize(ctx, GroupMetadata, PermissionRead, s.metadataLabel(quadList))
+}
+
 // DeleteMetadataQuads will delete the metadata relation quad and any property quads for the given Id
-func DeleteMetadataQuads(metadataID string) error {
-	quadList := GetMetadataQuadsByID(metadataID)
+func (s *MetadataService) DeleteMetadataQuads(ctx context.Context, metadataID string) error {
+	quadList := s.GetMetadataQuadsByID(metadataID)
+	if err := authorize(ctx, GroupMetadata, PermissionDelete, s.metadataLabel(quadList)); err != nil {
+		return err
+	}
 	tx := cayley.NewTransaction()
 	for _, q := range quadList {
 		tx.RemoveQuad(q)
 	}
-	err := store.ApplyTransaction(tx)
+	err := s.applyTransaction(tx)
+	if err == nil {
+		log.WithContext(ctx).WithField("metadata_id", metadataID).Info("Metadata deleted")
+	}
 	return err
 }
 
 // UpdateMetadata will add or update any properties of the metadata object
-func UpdateMetadata(metadata model.Metadata) error {
-	quadList := getMetadataPropertiesAsQuads(metadata)
+func (s *MetadataService) UpdateMetadata(ctx context.Context, metadata model.Metadata) error {
+	if err := authorize(ctx, GroupMetadata, PermissionWrite, s.metadataLabel(s.GetMetadataQuadsByID(string(metadata.ID)))); err != nil {
+		return err
+	}
+	quadList := getMetadataPropertiesAsQuads(ctx, metadata)
 
 	tx := cayley.NewTransaction()
 	for _, q := range quadList {
-		AddOrUpdateAsTransaction(tx, q)
+		s.AddOrUpdateAsTransaction(tx, q)
 	}
-	err := store.ApplyTransaction(tx)
+	err := s.applyTransaction(tx)
 	if err != nil {
 		return &DataStoreError{Message: "Error saving data", Err: err}
 	}
+	log.WithContext(ctx).WithField("metadata_id", string(metadata.ID)).Debug("Metadata updated")
 	return nil
 }
 
 // GetMetadataQuadsByID gets the identity quad as well as property quads for a given metadataId
-func GetMetadataQuadsByID(metadataID string) []quad.Quad {
+func (s *MetadataService) GetMetadataQuadsByID(metadataID string) []quad.Quad {
 	var metaQuadList []quad.Quad
 	it, _ := iterator.NewAnd(
-		store,
-		store.QuadIterator(quad.Object, store.ValueOf(quad.IRI(metadataID))),
-		store.QuadIterator(quad.Predicate, store.ValueOf(model.MetaidPredicate)),
+		s.store,
+		s.store.QuadIterator(quad.Object, s.store.ValueOf(quad.IRI(metadataID))),
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.MetaidPredicate)),
 	).Optimize()
 	defer it.Close()
 
-	it, _ = store.OptimizeIterator(it)
+	it, _ = s.store.OptimizeIterator(it)
 
 	for it.Next() {
 
-		metaQuad := store.Quad(it.Result())
+		metaQuad := s.store.Quad(it.Result())
 		metaQuadList = append(metaQuadList, metaQuad)
 	}
 
 	for _, direction := range []quad.Direction{quad.Subject} {
-		metait := store.QuadIterator(direction, store.ValueOf(quad.IRI(metadataID)))
+		metait := s.store.QuadIterator(direction, s.store.ValueOf(quad.IRI(metadataID)))
 		defer metait.Close()
 		for metait.Next() {
-			meta := store.Quad(metait.Result())
+			meta := s.store.Quad(metait.Result())
 			log.Debug("type found ", reflect.TypeOf(meta.Object))
 			log.Debug("has value ", meta.Object.String())
 			metaQuadList = append(metaQuadList, meta)
@@ -108,32 +161,31 @@ func GetMetadataQuadsByID(metadataID string) []quad.Quad {
 	return metaQuadList
 }
 
-// GetMetadataQuadsForRelationId will return all metadata quads and the metadata relations for a given relationId
-func GetMetadataQuadsForRelationID(relationID string) []quad.Quad {
-	var metaQuadList []quad.Quad
-	var metaIdList []quad.Value
+// MetadataIDsForRelation will return the ids of the metadata attached to a relation
+func (s *MetadataService) MetadataIDsForRelation(relationID string) []quad.IRI {
+	var metadataIDs []quad.IRI
 	it, _ := iterator.NewAnd(
-		store,
-		store.QuadIterator(quad.Subject, store.ValueOf(quad.IRI(relationID))),
-		store.QuadIterator(quad.Predicate, store.ValueOf(model.MetaidPredicate)),
+		s.store,
+		s.store.QuadIterator(quad.Subject, s.store.ValueOf(quad.IRI(relationID))),
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.MetaidPredicate)),
 	).Optimize()
 	defer it.Close()
 
-	it, _ = store.OptimizeIterator(it)
+	it, _ = s.store.OptimizeIterator(it)
 
 	for it.Next() {
-
-		metaQuad := store.Quad(it.Result())
-		metaIdList = append(metaIdList, metaQuad.Subject)
-	}
-	for _, metaid := range metaIdList {
-		for _, direction := range []quad.Direction{quad.Subject} {
-			metait := store.QuadIterator(direction, store.ValueOf(metaid))
-			defer metait.Close()
-			for metait.Next() {
-				metaQuadList = append(metaQuadList, store.Quad(it.Result()))
-			}
+		if metadataID, ok := s.store.Quad(it.Result()).Object.(quad.IRI); ok {
+			metadataIDs = append(metadataIDs, metadataID)
 		}
 	}
+	return metadataIDs
+}
+
+// GetMetadataQuadsForRelationId will return all metadata quads and the metadata relations for a given relationId
+func (s *MetadataService) GetMetadataQuadsForRelationID(relationID string) []quad.Quad {
+	var metaQuadList []quad.Quad
+	for _, metadataID := range s.MetadataIDsForRelation(relationID) {
+		metaQuadList = append(metaQuadList, s.GetMetadataQuadsByID(string(metadataID))...)
+	}
 	return metaQuadList
 }
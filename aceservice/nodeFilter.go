@@ -0,0 +1,118 @@
+package aceservice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/model"
+)
+
+// FilterOperator is the comparison applied by a PropertyFilter
+type FilterOperator string
+
+const (
+	FilterEqual        FilterOperator = "eq"
+	FilterPrefix       FilterOperator = "prefix"
+	FilterGreater      FilterOperator = "gt"
+	FilterGreaterEqual FilterOperator = "gte"
+	FilterLess         FilterOperator = "lt"
+	FilterLessEqual    FilterOperator = "lte"
+)
+
+var rangeOperators = map[FilterOperator]iterator.Operator{
+	FilterGreater:      iterator.CompareGT,
+	FilterGreaterEqual: iterator.CompareGTE,
+	FilterLess:         iterator.CompareLT,
+	FilterLessEqual:    iterator.CompareLTE,
+}
+
+// PropertyFilter restricts a node search to nodes with a property matching Value
+type PropertyFilter struct {
+	Key   string
+	Op    FilterOperator
+	Value string
+}
+
+// NewPropertyFilter will return a filter for the key, checking that the operator is known and that range values are numeric
+func NewPropertyFilter(key string, op FilterOperator, value string) (PropertyFilter, error) {
+	filter := PropertyFilter{Key: key, Op: op, Value: value}
+	switch op {
+	case FilterEqual, FilterPrefix:
+	case FilterGreater, FilterGreaterEqual, FilterLess, FilterLessEqual:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return filter, fmt.Errorf("value for %s %s must be numeric", key, op)
+		}
+	default:
+		return filter, fmt.Errorf("unknown operator %s for %s", op, key)
+	}
+	return filter, nil
+}
+
+// predicate will return the quad predicate the filter key is stored under
+func (f PropertyFilter) predicate() quad.IRI {
+	if f.Key == "name" {
+		return model.NamePredicate
+	}
+	return quad.IRI(f.Key)
+}
+
+// candidateValues will return each representation the filter value may be stored as.
+// Properties received as JSON are stored as raw values while N-Quad files store quoted strings
+func (f PropertyFilter) candidateValues() []quad.Value {
+	values := []quad.Value{quad.Raw(f.Value), quad.String(f.Value)}
+	if number, err := strconv.ParseFloat(f.Value, 64); err == nil {
+		values = append(values, quad.Float(number))
+		if number == float64(int64(number)) {
+			values = append(values, quad.Int(int64(number)))
+		}
+	}
+	if boolean, err := strconv.ParseBool(f.Value); err == nil {
+		values = append(values, quad.Bool(boolean))
+	}
+	return values
+}
+
+// subjectIterator will return an iterator over the subjects of quads matching the filter
+func (s *NodeService) subjectIterator(f PropertyFilter) graph.Iterator {
+	var objects graph.Iterator
+
+	switch f.Op {
+	case FilterPrefix:
+		objects = iterator.NewRegex(s.propertyValues(f), regexp.MustCompile("^"+regexp.QuoteMeta(f.Value)), s.store)
+	case FilterGreater, FilterGreaterEqual, FilterLess, FilterLessEqual:
+		number, _ := strconv.ParseFloat(f.Value, 64)
+		comparisons := []graph.Iterator{
+			iterator.NewComparison(s.propertyValues(f), rangeOperators[f.Op], quad.Float(number), s.store),
+		}
+		if number == float64(int64(number)) {
+			comparisons = append(comparisons,
+				iterator.NewComparison(s.propertyValues(f), rangeOperators[f.Op], quad.Int(int64(number)), s.store))
+		}
+		objects = iterator.NewOr(comparisons...)
+	default:
+		fixed := s.store.FixedIterator()
+		for _, value := range f.candidateValues() {
+			fixed.Add(s.store.ValueOf(value))
+		}
+		objects = fixed
+	}
+
+	return iterator.NewHasA(s.store,
+		iterator.NewAnd(
+			s.store,
+			s.store.QuadIterator(quad.Predicate, s.store.ValueOf(f.predicate())),
+			iterator.NewLinksTo(s.store, objects, quad.Object),
+		),
+		quad.Subject)
+}
+
+// propertyValues will return an iterator over the values stored for the filter key
+func (s *NodeService) propertyValues(f PropertyFilter) graph.Iterator {
+	return iterator.NewHasA(s.store,
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(f.predicate())),
+		quad.Object)
+}
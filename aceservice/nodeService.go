@@ -1,18 +1,116 @@
 package aceservice
 
 import (
+	"container/heap"
+	"context"
 	"fmt"
+	"sort"
 
 	"github.com/gkontos/gasket/model"
 	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/quad"
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/acemetrics"
 	"github.com/pborman/uuid"
 )
 
-// DeleteByID removes all nodes with the value of 'subject'.  This value may be the label, object, subject or predicate
-func DeleteByID(subject string) error {
+// NodeService reads and writes nodes within a graph store
+type NodeService struct {
+	*QuadService
+	relations *RelationService
+	types     *TypeService
+}
+
+// NewNodeService will return a NodeService for the given store
+func NewNodeService(store *cayley.Handle) *NodeService {
+	return &NodeService{
+		QuadService: NewQuadService(store),
+		relations:   NewRelationService(store),
+		types:       NewTypeService(store),
+	}
+}
+
+// DeleteByID removes all quads with the value of 'subject'.  This value may be the label, object, subject or predicate.
+// If the node has relations, a ConflictError is returned unless cascade is set, in which case the relations are deleted
+// along with their relation ids and metadata.  false is returned if the node is not found
+func (s *NodeService) DeleteByID(ctx context.Context, subject string, cascade bool) (bool, error) {
+	nodeQuads := s.quadsWithValue(quad.IRI(subject))
+	if len(nodeQuads) == 0 {
+		return false, nil
+	}
+
+	var relationQuads []quad.Quad
+	for _, q := range nodeQuads {
+		if isRelationQuad(q) {
+			relationQuads = append(relationQuads, q)
+		} else if err := authorize(ctx, GroupNodes, PermissionDelete, q.Label); err != nil {
+			return true, err
+		}
+	}
+	if len(relationQuads) > 0 && !cascade {
+		return true, &ConflictError{
+			Message: "Unable to delete node " + subject,
+			Err:     fmt.Errorf("the node has %d relations", len(relationQuads)),
+		}
+	}
+	for _, q := range relationQuads {
+		if err := authorize(ctx, GroupRelations, PermissionDelete, q.Label); err != nil {
+			return true, err
+		}
+	}
+
+	tx := cayley.NewTransaction()
+	for _, q := range nodeQuads {
+		tx.RemoveQuad(q)
+	}
+	if len(relationQuads) > 0 {
+		ids := s.relations.newRelationIDs()
+		for _, q := range relationQuads {
+			relationID := ids.of(q)
+			if relationID == "" {
+				continue
+			}
+			quadList, err := s.relations.relationQuads(string(relationID))
+			if err != nil {
+				return true, &DataStoreError{Message: "Unable to read relation " + string(relationID), Err: err}
+			}
+			for _, relationQuad := range quadList {
+				tx.RemoveQuad(relationQuad)
+			}
+		}
+	}
+	if err := s.applyTransaction(tx); err != nil {
+		return true, &DataStoreError{Message: "Error deleting node", Err: err}
+	}
+	log.WithContext(ctx).WithFields(log.Fields{"node_id": subject, "relations": len(relationQuads)}).Info("Node deleted")
+	return true, nil
+}
 
-	return store.RemoveNode(store.ValueOf(quad.IRI(subject)))
+// quadsWithValue will return every quad with the value in any direction
+func (s *NodeService) quadsWithValue(value quad.Value) []quad.Quad {
+	var quadList []quad.Quad
+	storeValue := s.store.ValueOf(value)
+	if storeValue == nil {
+		return quadList
+	}
+	scan := acemetrics.StartScan("quads_with_value")
+	defer scan.Done()
+	seen := make(map[string]bool)
+	for _, direction := range quad.Directions {
+		it := s.store.QuadIterator(direction, storeValue)
+		for it.Next() {
+			q := s.store.Quad(it.Result())
+			scan.Quad()
+			if !seen[quadKey(q)] {
+				seen[quadKey(q)] = true
+				quadList = append(quadList, q)
+			}
+		}
+		it.Close()
+	}
+	return quadList
 }
 
 // NodeToNodeProperties will return the properties map of the node as a list of NodeProperty
@@ -41,8 +139,41 @@ func NodeToNodeProperties(node model.Node) ([]model.NodeProperty, error) {
 	return nodeProperties, err
 }
 
+// GetNode will return the node with the given id.  false is returned if the node is not found
+func (s *NodeService) GetNode(ctx context.Context, ID string) (model.Node, bool, error) {
+	quadList := s.GetQuadsBySubject(ID)
+	if len(quadList) == 0 {
+		return model.Node{}, false, nil
+	}
+	node, err := QuadListToNode(quadList)
+	if err != nil {
+		return node, true, err
+	}
+	return node, true, authorize(ctx, GroupNodes, PermissionRead, node.Label)
+}
+
+// NodeQuads will return the quads with the node as subject or object.  An AuthorizationError is returned if the caller may
+// not read the node, and quads with a label the caller may not read are left out; relation quads are checked against
+// the relations group and property quads against the nodes group
+func (s *NodeService) NodeQuads(ctx context.Context, ID string) ([]quad.Quad, error) {
+	if _, _, err := s.GetNode(ctx, ID); err != nil {
+		return nil, err
+	}
+	var quadList []quad.Quad
+	for _, q := range s.GetQuads(ID) {
+		group := GroupNodes
+		if isRelationQuad(q) {
+			group = GroupRelations
+		}
+		if permitted(ctx, group, PermissionRead, q.Label) {
+			quadList = append(quadList, q)
+		}
+	}
+	return quadList, nil
+}
+
 // AddNode will save a node and the node properties as quads to the data store
-func AddNode(node model.Node) ([]quad.Quad, error) {
+func (s *NodeService) AddNode(ctx context.Context, node model.Node) ([]quad.Quad, error) {
 	var quadList []quad.Quad
 	nodeProperties, parseErr := NodeToNodeProperties(node)
 	nodeID := uuid.NewUUID()
@@ -50,6 +181,12 @@ func AddNode(node model.Node) ([]quad.Quad, error) {
 	if parseErr != nil {
 		return nil, parseErr
 	}
+	if err := authorize(ctx, GroupNodes, PermissionWrite, node.Label); err != nil {
+		return nil, err
+	}
+	if err := s.types.CheckNode(node, nil); err != nil {
+		return nil, err
+	}
 	var err error
 	tx := cayley.NewTransaction()
 	for _, nodeProperty := range nodeProperties {
@@ -60,22 +197,36 @@ func AddNode(node model.Node) ([]quad.Quad, error) {
 		tx.AddQuad(propertyQuad)
 		quadList = append(quadList, propertyQuad)
 	}
-	err = store.ApplyTransaction(tx)
+	err = s.applyTransaction(tx)
 	if err != nil {
 		return nil, &DataStoreError{Message: "Error saving data", Err: err}
 	}
+	log.WithContext(ctx).WithField("node_id", nodeID.String()).Debug("Node added")
 	return quadList, err
 }
 
-// UpdateNode will add or update any properties of the node
-func UpdateNode(node model.Node) ([]quad.Quad, error) {
+// UpdateNode will add or update any properties of the node.  The properties keep the label of the node unless a label is sent
+func (s *NodeService) UpdateNode(ctx context.Context, node model.Node) ([]quad.Quad, error) {
 
 	var quadList []quad.Quad
 	var saveErr error
+	current, err := QuadListToNode(s.GetQuadsBySubject(string(node.ID)))
+	if err != nil {
+		return nil, err
+	}
+	if node.Label == nil {
+		node.Label = current.Label
+	}
+	if err = authorize(ctx, GroupNodes, PermissionWrite, current.Label, node.Label); err != nil {
+		return nil, err
+	}
 	nodeProperties, parseErr := NodeToNodeProperties(node)
 	if parseErr != nil {
 		return nil, parseErr
 	}
+	if err = s.types.CheckNode(node, &current); err != nil {
+		return nil, err
+	}
 	tx := cayley.NewTransaction()
 	for _, nodeProperty := range nodeProperties {
 
@@ -84,15 +235,124 @@ func UpdateNode(node model.Node) ([]quad.Quad, error) {
 				nodeProperty.Predicate,
 				nodeProperty.Object,
 				nodeProperty.Label)
-			AddOrUpdateAsTransaction(tx, propertyQuad)
+			s.AddOrUpdateAsTransaction(tx, propertyQuad)
 			quadList = append(quadList, propertyQuad)
 		}
 	}
 
-	err := store.ApplyTransaction(tx)
+	err = s.applyTransaction(tx)
 	if err != nil {
 		saveErr = &DataStoreError{Message: "Error updating data", Err: err}
 		return nil, saveErr
 	}
+	log.WithContext(ctx).WithField("node_id", string(node.ID)).Debug("Node updated")
 	return quadList, nil
 }
+
+// ListNodes will return up to limit nodes ordered by id, starting after the node id 'after'.
+// Nodes are found by their name quad, and must match every filter.  Nodes the caller may not read are left out.
+// more will be true when additional nodes follow the returned page
+func (s *NodeService) ListNodes(ctx context.Context, filters []PropertyFilter, after string, limit int) (nodes []model.Node, more bool, err error) {
+	if err = AuthorizeGroup(ctx, GroupNodes, PermissionRead); err != nil {
+		return nil, false, err
+	}
+
+	var named graph.Iterator = iterator.NewHasA(s.store,
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.NamePredicate)),
+		quad.Subject)
+	if after != "" {
+		// the comparison filters the scan rather than seeking to the cursor: cayley has no ordered scan of
+		// the names, so every page still reads all named nodes and drops the ids up to the cursor
+		named = iterator.NewComparison(named, iterator.CompareGT, quad.IRI(after), s.store)
+	}
+	subjects := []graph.Iterator{named}
+	for _, filter := range filters {
+		subjects = append(subjects, s.subjectIterator(filter))
+	}
+	it, _ := iterator.NewAnd(s.store, subjects...).Optimize()
+	defer it.Close()
+
+	it, _ = s.store.OptimizeIterator(it)
+
+	// when every node may be read, only the ids of the page and of the node following it are kept
+	keep := 0
+	if _, restricted := PrincipalFromContext(ctx); !restricted && limit > 0 {
+		keep = limit + 1
+	}
+	page := newPageIDs(keep)
+
+	scan := acemetrics.StartScan("nodes")
+	for it.Next() {
+		scan.Quad()
+		if nodeID, ok := s.store.NameOf(it.Result()).(quad.IRI); ok {
+			page.add(string(nodeID))
+		}
+	}
+	scan.Done()
+	if err = it.Err(); err != nil {
+		return nil, false, &DataStoreError{Message: "Error reading nodes", Err: err}
+	}
+
+	for _, nodeID := range page.sorted() {
+		node, mappingErr := QuadListToNode(s.GetQuadsBySubject(nodeID))
+		if mappingErr != nil {
+			return nil, false, mappingErr
+		}
+		if !permitted(ctx, GroupNodes, PermissionRead, node.Label) {
+			continue
+		}
+		if limit > 0 && len(nodes) == limit {
+			more = true
+			break
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, more, nil
+}
+
+// pageIDs collects the distinct node ids found by a scan.  When size is above zero only the size smallest ids are kept
+type pageIDs struct {
+	size  int
+	found map[string]bool
+	ids   maxHeap
+}
+
+func newPageIDs(size int) *pageIDs {
+	return &pageIDs{size: size, found: make(map[string]bool)}
+}
+
+// add will keep the id unless it was found before, or size ids which sort before it are already kept
+func (p *pageIDs) add(ID string) {
+	if p.found[ID] {
+		return
+	}
+	if p.size > 0 && len(p.ids) == p.size {
+		if ID > p.ids[0] {
+			return
+		}
+		delete(p.found, heap.Pop(&p.ids).(string))
+	}
+	p.found[ID] = true
+	heap.Push(&p.ids, ID)
+}
+
+// sorted will return the kept ids in order
+func (p *pageIDs) sorted() []string {
+	ids := append([]string(nil), p.ids...)
+	sort.Strings(ids)
+	return ids
+}
+
+// maxHeap is a heap of strings with the largest at the root
+type maxHeap []string
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i] > h[j] }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(string)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
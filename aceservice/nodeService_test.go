@@ -0,0 +1,50 @@
+package aceservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListNodesPaging(t *testing.T) {
+	assert := assert.New(t)
+	s := NewNodeService(makeTestStore(t))
+	reader := &Principal{Subject: "gasket", Roles: []Role{{Groups: []string{GroupNodes}, Read: []string{"test"}}}}
+
+	for _, ctx := range []context.Context{context.Background(), WithPrincipal(context.Background(), reader)} {
+		var IDs []string
+		after, more := "", true
+		for more {
+			nodes, hasMore, err := s.ListNodes(ctx, nil, after, 2)
+			assert.NoError(err)
+			for _, node := range nodes {
+				IDs = append(IDs, string(node.ID))
+				after = string(node.ID)
+			}
+			more = hasMore
+		}
+		assert.Equal([]string{"123456789", "234567890", "345678901"}, IDs)
+
+		nodes, more, err := s.ListNodes(ctx, nil, "345678901", 2)
+		assert.NoError(err)
+		assert.Empty(nodes)
+		assert.False(more)
+	}
+}
+
+func TestPageIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	page := newPageIDs(3)
+	for _, ID := range []string{"e", "b", "f", "b", "a", "d", "c", "a"} {
+		page.add(ID)
+	}
+	assert.Equal([]string{"a", "b", "c"}, page.sorted())
+
+	page = newPageIDs(0)
+	for _, ID := range []string{"e", "b", "f", "b", "a"} {
+		page.add(ID)
+	}
+	assert.Equal([]string{"a", "b", "e", "f"}, page.sorted())
+}
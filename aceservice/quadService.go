@@ -2,12 +2,15 @@ package aceservice
 
 import (
 	"fmt"
+	"time"
 
 	"reflect"
 
 	"github.com/cayleygraph/cayley"
 	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/acemetrics"
 	"github.com/gkontos/gasket/model"
 )
 
@@ -55,10 +58,15 @@ func QuadListToMetadata(quads []quad.Quad) (model.Metadata, error) {
 	for _, q := range quads {
 		if q.Predicate == model.MetaidPredicate {
 
+			relationID, ok := q.Subject.(quad.IRI)
+			if !ok {
+				err = fmt.Errorf("The relation of metadataid=%s is not an IRI : %s", literalText(q.Object), q.Subject)
+				break
+			}
 			if metadata.ID.String() == "<>" {
 				metadata.ID = q.Object.(quad.IRI)
 			}
-			metadata.RelationID = q.Subject.(quad.IRI)
+			metadata.RelationID = relationID
 
 		} else {
 			if metadata.ID != "" && q.Subject != metadata.ID {
@@ -78,9 +86,9 @@ func QuadListToMetadata(quads []quad.Quad) (model.Metadata, error) {
 }
 
 // AddQuad will save the given quad to the store
-func AddQuad(q quad.Quad) error {
+func (s *QuadService) AddQuad(q quad.Quad) error {
 
-	err := store.AddQuad(q)
+	err := s.store.AddQuad(q)
 	if err != nil {
 		dberr := &DataStoreError{Message: "Unable to save to datastore", Err: err}
 		return dberr
@@ -89,9 +97,9 @@ func AddQuad(q quad.Quad) error {
 }
 
 // DeleteQuad will delete the given quad from the store
-func DeleteQuad(q quad.Quad) error {
+func (s *QuadService) DeleteQuad(q quad.Quad) error {
 
-	err := store.RemoveQuad(q)
+	err := s.store.RemoveQuad(q)
 	if err != nil {
 		dberr := &DataStoreError{Message: "Unable to delete from datastore", Err: err}
 		return dberr
@@ -101,11 +109,11 @@ func DeleteQuad(q quad.Quad) error {
 
 // AddOrUpdate quad will add the new quad to the store
 // if a quad is found matching the subject and prediate, the existing quad will be deleted
-func AddOrUpdate(q quad.Quad) error {
+func (s *QuadService) AddOrUpdate(q quad.Quad) error {
 
 	tx := cayley.NewTransaction()
-	AddOrUpdateAsTransaction(tx, q)
-	err := store.ApplyTransaction(tx)
+	s.AddOrUpdateAsTransaction(tx, q)
+	err := s.applyTransaction(tx)
 	if err != nil {
 		dberr := &DataStoreError{Message: "Transaction error", Err: err}
 		return dberr
@@ -113,14 +121,14 @@ func AddOrUpdate(q quad.Quad) error {
 	return nil
 }
 
-func AddOrUpdateAsTransaction(tx *graph.Transaction, q quad.Quad) {
+func (s *QuadService) AddOrUpdateAsTransaction(tx *graph.Transaction, q quad.Quad) {
 
 	for _, direction := range []quad.Direction{quad.Subject} {
-		it := store.QuadIterator(direction, store.ValueOf(q.Subject))
+		it := s.store.QuadIterator(direction, s.store.ValueOf(q.Subject))
 
 		for it.Next() {
 
-			foundQuad := store.Quad(it.Result())
+			foundQuad := s.store.Quad(it.Result())
 
 			if foundQuad.Predicate == q.Predicate {
 				tx.RemoveQuad(foundQuad)
@@ -131,18 +139,28 @@ func AddOrUpdateAsTransaction(tx *graph.Transaction, q quad.Quad) {
 
 }
 
+// applyTransaction will apply the transaction to the store and record it in the store metrics
+func (s *QuadService) applyTransaction(tx *graph.Transaction) error {
+	start := time.Now()
+	err := s.store.ApplyTransaction(tx)
+	acemetrics.ObserveTransaction(tx, start, err)
+	return err
+}
+
 // GetQuads will return all quads will subject or objects containing the parameter {subject}
-func GetQuads(subject string) []quad.Quad {
+func (s *QuadService) GetQuads(subject string) []quad.Quad {
 
 	var quadList []quad.Quad
+	scan := acemetrics.StartScan("quads")
+	defer scan.Done()
 
 	// see writer/single.go for an example function
 	for _, direction := range []quad.Direction{quad.Subject, quad.Object} {
-		it := store.QuadIterator(direction, store.ValueOf(quad.IRI(subject)))
+		it := s.store.QuadIterator(direction, s.store.ValueOf(quad.IRI(subject)))
 		for it.Next() {
 
-			quadList = append(quadList, store.Quad(it.Result()))
-
+			quadList = append(quadList, s.store.Quad(it.Result()))
+			scan.Quad()
 		}
 		it.Close()
 	}
@@ -150,19 +168,121 @@ func GetQuads(subject string) []quad.Quad {
 }
 
 // GetQuadsBySubject will return all quads will subject containing the parameter {subject}
-func GetQuadsBySubject(subject string) []quad.Quad {
+func (s *QuadService) GetQuadsBySubject(subject string) []quad.Quad {
 
 	var quadList []quad.Quad
+	scan := acemetrics.StartScan("quads_by_subject")
+	defer scan.Done()
 
 	// see writer/single.go for an example function
 	for _, direction := range []quad.Direction{quad.Subject} {
-		it := store.QuadIterator(direction, store.ValueOf(quad.IRI(subject)))
+		it := s.store.QuadIterator(direction, s.store.ValueOf(quad.IRI(subject)))
 		for it.Next() {
 
-			quadList = append(quadList, store.Quad(it.Result()))
-
+			quadList = append(quadList, s.store.Quad(it.Result()))
+			scan.Quad()
 		}
 		it.Close()
 	}
 	return quadList
 }
+
+// quadKey will return a key identifying a quad, regardless of the quad.Value types used to store it
+func quadKey(q quad.Quad) string {
+	return quad.StringOf(q.Subject) + " " +
+		quad.StringOf(q.Predicate) + " " +
+		quad.StringOf(q.Object) + " " +
+		quad.StringOf(q.Label)
+}
+
+// QuadExists will return true if the quad is found in the store
+func (s *QuadService) QuadExists(q quad.Quad) bool {
+	key := quadKey(q)
+	it := s.store.QuadIterator(quad.Subject, s.store.ValueOf(q.Subject))
+	defer it.Close()
+	for it.Next() {
+		if quadKey(s.store.Quad(it.Result())) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeExists will return true if the store holds a name for the node
+func (s *QuadService) NodeExists(ID quad.IRI) bool {
+	return s.hasQuad(quad.Subject, ID, model.NamePredicate)
+}
+
+// RelationExists will return true if the store holds a relation id quad for the relation
+func (s *QuadService) RelationExists(ID quad.IRI) bool {
+	return s.hasQuad(quad.Object, ID, model.RelationidPredicate)
+}
+
+// hasQuad will return true if a quad with the value in direction d has the predicate
+func (s *QuadService) hasQuad(d quad.Direction, value quad.Value, predicate quad.IRI) bool {
+	storeValue := s.store.ValueOf(value)
+	if storeValue == nil {
+		return false
+	}
+	it, _ := iterator.NewAnd(
+		s.store,
+		s.store.QuadIterator(d, storeValue),
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(predicate)),
+	).Optimize()
+	defer it.Close()
+	return it.Next()
+}
+
+// missingNodes will return the ids which are not nodes within the store
+func (s *QuadService) missingNodes(IDs ...quad.IRI) []string {
+	var missing []string
+	for _, ID := range IDs {
+		if !s.NodeExists(ID) {
+			missing = append(missing, string(ID))
+		}
+	}
+	return missing
+}
+
+// relationIDQuads will return the hasRelationId quads for a relation id
+func (s *QuadService) relationIDQuads(ID string) []quad.Quad {
+	var relationIDQuads []quad.Quad
+	it, _ := iterator.NewAnd(
+		s.store,
+		s.store.QuadIterator(quad.Object, s.store.ValueOf(quad.IRI(ID))),
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.RelationidPredicate)),
+	).Optimize()
+	defer it.Close()
+
+	it, _ = s.store.OptimizeIterator(it)
+	for it.Next() {
+		relationIDQuads = append(relationIDQuads, s.store.Quad(it.Result()))
+	}
+	return relationIDQuads
+}
+
+// relationLabel will return the label of the relation with the given id.  false is returned if the relation is not found
+func (s *QuadService) relationLabel(ID string) (quad.Value, bool) {
+	for _, relationIDQuad := range s.relationIDQuads(ID) {
+		if baseQuad, err := RelationSubjectToQuad(relationIDQuad.Subject); err == nil {
+			return baseQuad.Label, true
+		}
+	}
+	return nil, false
+}
+
+// eachWithPredicate will call fn for every quad in the store with the predicate
+func (s *QuadService) eachWithPredicate(predicate quad.IRI, scanName string, fn func(q quad.Quad)) {
+	value := s.store.ValueOf(predicate)
+	if value == nil {
+		return
+	}
+	scan := acemetrics.StartScan(scanName)
+	defer scan.Done()
+	it := s.store.QuadIterator(quad.Predicate, value)
+	defer it.Close()
+	for it.Next() {
+		scan.Quad()
+		fn(s.store.Quad(it.Result()))
+	}
+}
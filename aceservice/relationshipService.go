@@ -1,16 +1,38 @@
 package aceservice
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/cayleygraph/cayley"
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/acemetrics"
 	log "github.com/gkontos/gasket/acelog"
 	"github.com/gkontos/gasket/model"
 	"github.com/pborman/uuid"
 )
 
+// RelationService reads and writes relations within a graph store
+type RelationService struct {
+	*QuadService
+	metadata *MetadataService
+	types    *TypeService
+}
+
+// NewRelationService will return a RelationService for the given store
+func NewRelationService(store *cayley.Handle) *RelationService {
+	return &RelationService{
+		QuadService: NewQuadService(store),
+		metadata:    NewMetadataService(store),
+		types:       NewTypeService(store),
+	}
+}
+
 // GetRelationshipSubject will return the subject of a relation as a quad.
 func GetRelationshipSubject(baseQuad quad.Quad) (string, error) {
 	bytes, err := json.Marshal(baseQuad)
@@ -18,86 +40,362 @@ func GetRelationshipSubject(baseQuad quad.Quad) (string, error) {
 	return string(bytes), err
 }
 
-// GetRelation will return the relation for an ID
-func GetRelation(ID string) model.Relation {
+// RelationSubjectToQuad will return the base quad encoded within the subject of a relation id quad
+func RelationSubjectToQuad(subject quad.Value) (quad.Quad, error) {
+	var baseQuad quad.Quad
+	var encoded string
+	switch v := subject.(type) {
+	case quad.IRI:
+		encoded = string(v)
+	case quad.String:
+		encoded = string(v)
+	default:
+		encoded = quad.StringOf(v)
+	}
+	err := json.Unmarshal([]byte(encoded), &baseQuad)
+	// quads are decoded as raw values, they must be typed to match the quad in the store
+	baseQuad.Subject = typedValue(baseQuad.Subject)
+	baseQuad.Predicate = typedValue(baseQuad.Predicate)
+	baseQuad.Object = typedValue(baseQuad.Object)
+	baseQuad.Label = typedValue(baseQuad.Label)
+	return baseQuad, err
+}
+
+// typedValue will parse a raw value into an IRI or string value
+func typedValue(v quad.Value) quad.Value {
+	raw, ok := v.(quad.Raw)
+	if !ok {
+		return v
+	}
+	if len(raw) > 1 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		if unquoted, err := strconv.Unquote(string(raw)); err == nil {
+			return quad.String(unquoted)
+		}
+	}
+	return quad.StringToValue(string(raw))
+}
+
+// QuadToRelation will map the base quad of a relation to a Relation
+func QuadToRelation(ID quad.IRI, baseQuad quad.Quad) model.Relation {
+	return model.Relation{
+		ID:       ID,
+		SourceID: quad.IRI(model.UnEscapeIRI(baseQuad.Subject)),
+		Type:     quad.IRI(model.UnEscapeIRI(baseQuad.Predicate)),
+		TargetID: quad.IRI(model.UnEscapeIRI(baseQuad.Object)),
+		Label:    baseQuad.Label,
+	}
+}
+
+// RelationIDIndex will return the id of every relation in the store keyed by its base quad
+func (s *RelationService) RelationIDIndex() map[string]quad.IRI {
+	index := make(map[string]quad.IRI)
+
+	scan := acemetrics.StartScan("relation_ids")
+	defer scan.Done()
+	it := s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.RelationidPredicate))
+	defer it.Close()
+	for it.Next() {
+		relationIDQuad := s.store.Quad(it.Result())
+		scan.Quad()
+		relationID, ok := relationIDQuad.Object.(quad.IRI)
+		if !ok {
+			continue
+		}
+		baseQuad, err := RelationSubjectToQuad(relationIDQuad.Subject)
+		if err != nil {
+			log.Error("Unable to parse relation ", relationID, " : ", err)
+			continue
+		}
+		index[quadKey(baseQuad)] = relationID
+	}
+	return index
+}
+
+// relationID will return the id of the relation with the base quad, read from the relation id quad whose subject
+// encodes the base quad.  "" is returned if there is no such quad
+func (s *RelationService) relationID(baseQuad quad.Quad) quad.IRI {
+	predicate := s.store.ValueOf(model.RelationidPredicate)
+	if predicate == nil {
+		return ""
+	}
+	for _, encoded := range relationSubjects(baseQuad) {
+		for _, subject := range []quad.Value{quad.IRI(encoded), quad.String(encoded)} {
+			value := s.store.ValueOf(subject)
+			if value == nil {
+				continue
+			}
+			it, _ := iterator.NewAnd(
+				s.store,
+				s.store.QuadIterator(quad.Subject, value),
+				s.store.QuadIterator(quad.Predicate, predicate),
+			).Optimize()
+			for it.Next() {
+				if relationID, ok := s.store.Quad(it.Result()).Object.(quad.IRI); ok {
+					it.Close()
+					return relationID
+				}
+			}
+			it.Close()
+		}
+	}
+	return ""
+}
+
+// relationSubjects will return the encodings of the base quad which may be found in the subject of its relation id quad:
+// the one written by the relation endpoints, and the one of N-Quads files written by hand, where "<" and ">" are not
+// escaped and the label is always present
+func relationSubjects(baseQuad quad.Quad) []string {
+	var subjects []string
+	if encoded, err := GetRelationshipSubject(baseQuad); err == nil {
+		subjects = append(subjects, encoded)
+	}
+
+	written := struct {
+		Subject   string `json:"subject"`
+		Predicate string `json:"predicate"`
+		Object    string `json:"object"`
+		Label     string `json:"label"`
+	}{
+		Subject:   baseQuad.Subject.String(),
+		Predicate: baseQuad.Predicate.String(),
+		Object:    baseQuad.Object.String(),
+	}
+	if baseQuad.Label != nil {
+		written.Label = baseQuad.Label.String()
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(written); err == nil {
+		subjects = append(subjects, strings.TrimSuffix(buf.String(), "\n"))
+	}
+	return subjects
+}
+
+// relationIDs resolves the ids of the relation quads read while serving a request.  The id of each quad is looked up
+// with relationID once, and remembered whether or not it was found
+type relationIDs struct {
+	relations *RelationService
+	found     map[string]quad.IRI
+}
+
+// newRelationIDs will return a relationIDs for one request
+func (s *RelationService) newRelationIDs() *relationIDs {
+	return &relationIDs{relations: s, found: make(map[string]quad.IRI)}
+}
+
+// of will return the id of the relation with the base quad, or "" if the quad has no relation id
+func (r *relationIDs) of(baseQuad quad.Quad) quad.IRI {
+	key := quadKey(baseQuad)
+	relationID, ok := r.found[key]
+	if !ok {
+		relationID = r.relations.relationID(baseQuad)
+		r.found[key] = relationID
+	}
+	return relationID
+}
+
+// isRelationQuad will return true if the quad links two nodes.  Property quads have a literal object, while the
+// relation id and metadata id quads use reserved predicates
+func isRelationQuad(q quad.Quad) bool {
+	if q.Predicate == model.RelationidPredicate || q.Predicate == model.MetaidPredicate {
+		return false
+	}
+	_, subjectIsIRI := q.Subject.(quad.IRI)
+	_, objectIsIRI := q.Object.(quad.IRI)
+	return subjectIsIRI && objectIsIRI
+}
+
+// nodeRelations will return the relations of a node.  d is quad.Subject for relations from the node, quad.Object for relations to the node.
+// If types is not empty, only relations of the given types are returned.  Relation ids are resolved with ids
+func (s *RelationService) nodeRelations(nodeID quad.IRI, d quad.Direction, types []quad.IRI, ids *relationIDs) []model.Relation {
+	var relations []model.Relation
+
+	scan := acemetrics.StartScan("node_relations")
+	defer scan.Done()
+	it := s.store.QuadIterator(d, s.store.ValueOf(nodeID))
+	defer it.Close()
+	for it.Next() {
+		baseQuad := s.store.Quad(it.Result())
+		scan.Quad()
+		if !isRelationQuad(baseQuad) || !hasType(baseQuad, types) {
+			continue
+		}
+		relations = append(relations, QuadToRelation(ids.of(baseQuad), baseQuad))
+	}
+	return relations
+}
+
+// hasType will return true if types is empty or contains the predicate of the quad
+func hasType(q quad.Quad, types []quad.IRI) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, relationType := range types {
+		if q.Predicate == relationType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRelation will return the relation for an ID.  An empty relation is returned if the relation is not found
+func (s *RelationService) GetRelation(ctx context.Context, ID string) (model.Relation, error) {
 
 	var relation model.Relation
-	var relationQuad quad.Quad
 	var foundQuad quad.Quad
 
 	it, _ := iterator.NewAnd(
-		store,
-		store.QuadIterator(quad.Object, store.ValueOf(quad.IRI(ID))),
-		store.QuadIterator(quad.Predicate, store.ValueOf(model.RelationidPredicate)),
+		s.store,
+		s.store.QuadIterator(quad.Object, s.store.ValueOf(quad.IRI(ID))),
+		s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.RelationidPredicate)),
 	).Optimize()
 	defer it.Close()
 
-	it, _ = store.OptimizeIterator(it)
+	it, _ = s.store.OptimizeIterator(it)
 
 	for it.Next() {
 		// we are only expecting a single quad with a specific id, so once found, break
-		foundQuad = store.Quad(it.Result())
+		foundQuad = s.store.Quad(it.Result())
 
 		break
 	}
 	if _, ok := foundQuad.Subject.(quad.Value); ok {
 
-		if err := json.Unmarshal([]byte(foundQuad.Subject.Native().(string)), &relationQuad); err == nil {
+		if relationQuad, err := RelationSubjectToQuad(foundQuad.Subject); err == nil {
 
-			relation.ID = quad.IRI(ID)
-			relation.SourceID = quad.IRI(model.UnEscapeIRI(relationQuad.Subject))
-			relation.Type = quad.IRI(model.UnEscapeIRI(relationQuad.Predicate))
-			relation.TargetID = quad.IRI(model.UnEscapeIRI(relationQuad.Object))
-			relation.Label = relationQuad.Label
+			relation = QuadToRelation(quad.IRI(ID), relationQuad)
 		} else {
-			log.Error(err)
+			log.WithContext(ctx).WithField("relation_id", ID).Error("Unable to parse relation : ", err)
 		}
 	}
-	return relation
+	if relation.ID == "" {
+		return relation, nil
+	}
+	return relation, authorize(ctx, GroupRelations, PermissionRead, relation.Label)
 }
 
-// DeleteByRelationID will Delete the the relation quad, and any metadata quads for the given relationid
-func DeleteByRelationID(ID string) error {
-	var deleteList []quad.Quad
-	var relationQuad quad.Quad
-	var err error
+// relationQuads will return the relation id quads, the base quad and the metadata quads for the given relationid
+func (s *RelationService) relationQuads(ID string) ([]quad.Quad, error) {
 	// get the relationquad
-	it, _ := iterator.NewAnd(
-		store,
-		store.QuadIterator(quad.Object, store.ValueOf(quad.IRI(ID))),
-		store.QuadIterator(quad.Predicate, store.ValueOf(model.RelationidPredicate)),
-	).Optimize()
-	defer it.Close()
-
-	it, _ = store.OptimizeIterator(it)
-	for it.Next() {
-
-		relationQuad = store.Quad(it.Result())
-		deleteList = append(deleteList, relationQuad)
-	}
+	relationQuads := s.relationIDQuads(ID)
 	// get the baseQuad
-	baseQuad := quad.Quad{}
-	for _, foundRelation := range deleteList {
-
-		err = json.Unmarshal([]byte(foundRelation.Subject.String()), baseQuad)
-		deleteList = append(deleteList, baseQuad)
+	for _, foundRelation := range relationQuads {
+		baseQuad, err := RelationSubjectToQuad(foundRelation.Subject)
+		if err != nil {
+			return nil, err
+		}
+		relationQuads = append(relationQuads, baseQuad)
 	}
+	// get metadata quads
+	return append(relationQuads, s.metadata.GetMetadataQuadsForRelationID(ID)...), nil
+}
 
+// DeleteByRelationID will Delete the the relation quad, and any metadata quads for the given relationid
+func (s *RelationService) DeleteByRelationID(ctx context.Context, ID string) error {
+	if label, found := s.relationLabel(ID); found {
+		if err := authorize(ctx, GroupRelations, PermissionDelete, label); err != nil {
+			return err
+		}
+	}
+	deleteList, err := s.relationQuads(ID)
 	if err == nil {
-		// get metadata quads
-		metadataQuads := GetMetadataQuadsForRelationID(ID)
-		deleteList = append(deleteList, metadataQuads...)
 		tx := cayley.NewTransaction()
 		for _, quad := range deleteList {
 			tx.RemoveQuad(quad)
 		}
-		err = store.ApplyTransaction(tx)
+		err = s.applyTransaction(tx)
+	}
+	if err == nil {
+		log.WithContext(ctx).WithFields(log.Fields{"relation_id": ID, "quads": len(deleteList)}).Info("Relation deleted")
 	}
 	return err
 }
 
-// AddQuadRelationship will add a quad and a relationId quad to the underlying datastore
-func AddQuadRelationship(relation *model.Relation) error {
+// UpdateRelation will replace the base quad of the relation with relation.ID and rewrite its relation id quad in a single transaction.
+// The relation id is kept, so metadata attached to the relation is unchanged.  Empty fields of relation keep their current value,
+// a changed source or target must be an existing node.
+// false is returned if the relation does not exist
+func (s *RelationService) UpdateRelation(ctx context.Context, relation *model.Relation) (bool, error) {
+	relationIDQuads := s.relationIDQuads(string(relation.ID))
+	if len(relationIDQuads) == 0 {
+		return false, nil
+	}
+
+	tx := cayley.NewTransaction()
+	var currentQuad quad.Quad
+	for _, relationIDQuad := range relationIDQuads {
+		baseQuad, err := RelationSubjectToQuad(relationIDQuad.Subject)
+		if err != nil {
+			return true, &DataStoreError{Message: "Unable to parse relation " + string(relation.ID), Err: err}
+		}
+		currentQuad = baseQuad
+		tx.RemoveQuad(relationIDQuad)
+		tx.RemoveQuad(baseQuad)
+	}
+	current := QuadToRelation(relation.ID, currentQuad)
+
+	if relation.SourceID == "" {
+		relation.SourceID = current.SourceID
+	}
+	if relation.Type == "" {
+		relation.Type = current.Type
+	}
+	if relation.TargetID == "" {
+		relation.TargetID = current.TargetID
+	}
+	if relation.Label == nil {
+		relation.Label = current.Label
+	}
+	if err := authorize(ctx, GroupRelations, PermissionWrite, current.Label, relation.Label); err != nil {
+		return true, err
+	}
+
+	var changed []quad.IRI
+	if relation.SourceID != current.SourceID {
+		changed = append(changed, relation.SourceID)
+	}
+	if relation.TargetID != current.TargetID {
+		changed = append(changed, relation.TargetID)
+	}
+	if err := missingReferences("nodes", s.missingNodes(changed...)); err != nil {
+		return true, err
+	}
+	if err := s.types.CheckRelation(*relation); err != nil {
+		return true, err
+	}
+	relationQuad := quad.Make(relation.SourceID, relation.Type, relation.TargetID, relation.Label)
+	if quadKey(relationQuad) != quadKey(currentQuad) && s.QuadExists(relationQuad) {
+		return true, &ConflictError{Message: "Unable to update relation " + string(relation.ID), Err: fmt.Errorf("relation already exists")}
+	}
+	relationSubject, parseErr := GetRelationshipSubject(relationQuad)
+	if parseErr != nil {
+		return true, parseErr
+	}
+	tx.AddQuad(relationQuad)
+	tx.AddQuad(quad.Make(quad.IRI(relationSubject), model.RelationidPredicate, relation.ID, ""))
+
+	if err := s.applyTransaction(tx); err != nil {
+		return true, &DataStoreError{Message: "Error saving data", Err: err}
+	}
+	log.WithContext(ctx).WithField("relation_id", string(relation.ID)).Debug("Relation updated")
+	return true, nil
+}
+
+// AddQuadRelationship will add a quad and a relationId quad to the underlying datastore.  When checkReferences is set
+// a MissingReferenceError is returned if the source or target node does not exist; bulk loads may skip the check
+func (s *RelationService) AddQuadRelationship(ctx context.Context, relation *model.Relation, checkReferences bool) error {
+	if err := authorize(ctx, GroupRelations, PermissionWrite, relation.Label); err != nil {
+		return err
+	}
+	if checkReferences {
+		if err := missingReferences("nodes", s.missingNodes(relation.SourceID, relation.TargetID)); err != nil {
+			return err
+		}
+	}
+	if err := s.types.CheckRelation(*relation); err != nil {
+		return err
+	}
 	relation.ID = quad.IRI(uuid.NewUUID().String())
 
 	relationQuad := quad.Make(quad.IRI(relation.SourceID),
@@ -119,6 +417,9 @@ func AddQuadRelationship(relation *model.Relation) error {
 	tx.AddQuad(relationQuad)
 	tx.AddQuad(relationIDQuad)
 
-	err := store.ApplyTransaction(tx)
+	err := s.applyTransaction(tx)
+	if err == nil {
+		log.WithContext(ctx).WithField("relation_id", string(relation.ID)).Debug("Relation added")
+	}
 	return err
 }
@@ -0,0 +1,44 @@
+package aceservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelationIDs(t *testing.T) {
+	assert := assert.New(t)
+	s := NewRelationService(makeTestStore(t))
+
+	// relations written by the relation endpoints are looked up by the encoding of their quad
+	relation := &model.Relation{SourceID: "234567890", Type: "copied", TargetID: "345678901"}
+	assert.NoError(s.AddQuadRelationship(context.Background(), relation, true))
+	ids := s.newRelationIDs()
+	relations := s.nodeRelations("234567890", quad.Subject, nil, ids)
+	if assert.Len(relations, 1) {
+		assert.Equal(relation.ID, relations[0].ID)
+	}
+
+	// the test data encodes the quads of its relation ids as they are written by hand in N-Quads
+	relations = s.nodeRelations("234567890", quad.Object, nil, ids)
+	assert.Len(relations, 2)
+	for _, r := range relations {
+		switch r.SourceID {
+		case "123456789":
+			assert.Equal(quad.IRI("abcdefghij001"), r.ID)
+		case "345678901":
+			assert.Equal(quad.IRI("klmnopqrst001"), r.ID)
+		}
+	}
+	assert.Len(ids.found, 3)
+
+	// quads without a relation id are remembered, so that they are looked up once
+	admired := quad.Make(quad.IRI("123456789"), quad.IRI("admired"), quad.IRI("345678901"), nil)
+	assert.Equal(quad.IRI(""), ids.of(admired))
+	relationID, found := ids.found[quadKey(admired)]
+	assert.True(found)
+	assert.Equal(quad.IRI(""), relationID)
+}
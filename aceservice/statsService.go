@@ -0,0 +1,100 @@
+package aceservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/acemetrics"
+	"github.com/gkontos/gasket/model"
+)
+
+// StatsService counts and checks the objects within a graph store
+type StatsService struct {
+	*QuadService
+	relations *RelationService
+}
+
+// NewStatsService will return a StatsService for the given store
+func NewStatsService(store *cayley.Handle) *StatsService {
+	return &StatsService{
+		QuadService: NewQuadService(store),
+		relations:   NewRelationService(store),
+	}
+}
+
+// Stats will return the number of nodes, relations, metadata, types and quads within the store
+func (s *StatsService) Stats(ctx context.Context) (model.GraphStats, error) {
+	stats := model.GraphStats{Quads: s.store.Size()}
+	if err := AuthorizeGroup(ctx, GroupAdmin, PermissionRead); err != nil {
+		return stats, err
+	}
+
+	nodes := make(map[string]bool)
+	s.eachWithPredicate(model.NamePredicate, "stats", func(q quad.Quad) {
+		nodes[quad.StringOf(q.Subject)] = true
+	})
+	stats.Nodes = len(nodes)
+	s.eachWithPredicate(model.RelationidPredicate, "stats", func(q quad.Quad) { stats.Relations++ })
+	s.eachWithPredicate(model.MetaidPredicate, "stats", func(q quad.Quad) { stats.Metadata++ })
+	s.eachWithPredicate(model.TypeDefinitionPredicate, "stats", func(q quad.Quad) { stats.Types++ })
+	return stats, nil
+}
+
+// Check will return the problems found with the relations and metadata of the store : relation ids which cannot be read,
+// relations whose quad or nodes are missing, quads between nodes without a relation id and metadata of missing relations
+func (s *StatsService) Check(ctx context.Context) ([]model.IntegrityIssue, error) {
+	if err := AuthorizeGroup(ctx, GroupAdmin, PermissionRead); err != nil {
+		return nil, err
+	}
+	issues := []model.IntegrityIssue{}
+	issue := func(kind string, ID quad.Value, format string, args ...interface{}) {
+		issues = append(issues, model.IntegrityIssue{Kind: kind, ID: literalText(ID), Message: fmt.Sprintf(format, args...)})
+	}
+
+	s.eachWithPredicate(model.RelationidPredicate, "check", func(q quad.Quad) {
+		baseQuad, err := RelationSubjectToQuad(q.Subject)
+		if err != nil {
+			issue(model.IssueInvalidRelation, q.Object, "unable to read the relation quad : %v", err)
+			return
+		}
+		if !s.QuadExists(baseQuad) {
+			issue(model.IssueMissingQuad, q.Object, "the quad of the relation is not in the store")
+		}
+		relation := QuadToRelation("", baseQuad)
+		if missing := s.missingNodes(relation.SourceID, relation.TargetID); len(missing) > 0 {
+			issue(model.IssueMissingNode, q.Object, "nodes not found : %s", strings.Join(missing, ", "))
+		}
+	})
+
+	index := s.relations.RelationIDIndex()
+	scan := acemetrics.StartScan("check")
+	it := s.store.QuadsAllIterator()
+	for it.Next() {
+		q := s.store.Quad(it.Result())
+		scan.Quad()
+		if !isRelationQuad(q) {
+			continue
+		}
+		if _, ok := index[quadKey(q)]; !ok {
+			issue(model.IssueUnlinkedRelation, q.Subject, "%s %s %s has no relation id",
+				literalText(q.Subject), literalText(q.Predicate), literalText(q.Object))
+		}
+	}
+	err := it.Err()
+	it.Close()
+	scan.Done()
+	if err != nil {
+		return issues, &DataStoreError{Message: "Error reading quads", Err: err}
+	}
+
+	s.eachWithPredicate(model.MetaidPredicate, "check", func(q quad.Quad) {
+		relationID, ok := q.Subject.(quad.IRI)
+		if !ok || !s.RelationExists(relationID) {
+			issue(model.IssueMissingRelation, q.Object, "the relation %s of the metadata is not in the store", literalText(q.Subject))
+		}
+	})
+	return issues, nil
+}
@@ -0,0 +1,102 @@
+package aceservice
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/cayleygraph/cayley"
+	_ "github.com/cayleygraph/cayley/graph/memstore"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/quad/cquads"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestStore will return a memstore holding the quads of the test data used by the controller tests
+func makeTestStore(t *testing.T) *cayley.Handle {
+	f, err := os.Open("./../data/testdata-3.nq")
+	if err != nil {
+		t.Fatalf("Failed to open test data : %v", err)
+	}
+	defer f.Close()
+
+	h, err := cayley.NewGraph("memstore", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to setup test datastore : %v", err)
+	}
+	dec := cquads.NewDecoder(f)
+	for q, err := dec.Unmarshal(); err == nil; q, err = dec.Unmarshal() {
+		h.AddQuad(q)
+	}
+	return h
+}
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+	store := makeTestStore(t)
+
+	stats, err := NewStatsService(store).Stats(context.Background())
+	assert.NoError(err)
+	assert.Equal(model.GraphStats{Nodes: 3, Relations: 2, Metadata: 3, Types: 0, Quads: 34}, stats)
+
+	viewer := &Principal{Subject: "gasket", Roles: []Role{{Groups: []string{GroupNodes}, Read: []string{Wildcard}}}}
+	_, err = NewStatsService(store).Stats(WithPrincipal(context.Background(), viewer))
+	assert.IsType(&AuthorizationError{}, err)
+}
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+	store := makeTestStore(t)
+
+	issues, err := NewStatsService(store).Check(context.Background())
+	assert.NoError(err)
+	assert.Empty(issues)
+
+	// a relation id whose quad was never written, between a node and a node which does not exist
+	store.AddQuad(quad.Make(
+		quad.String(`{"subject":"<123456789>","predicate":"<copied>","object":"<999999999>","label":""}`),
+		model.RelationidPredicate, quad.IRI("danglingrel01"), nil))
+	// a relation quad without a relation id
+	store.AddQuad(quad.Make(quad.IRI("123456789"), quad.IRI("admired"), quad.IRI("345678901"), nil))
+	// metadata of a relation which does not exist
+	store.AddQuad(quad.Make(quad.IRI("nosuchrel001"), model.MetaidPredicate, quad.IRI("danglingmeta1"), nil))
+
+	issues, err = NewStatsService(store).Check(context.Background())
+	assert.NoError(err)
+	sort.Sort(byIssue(issues))
+	assert.Equal([]model.IntegrityIssue{
+		{Kind: model.IssueUnlinkedRelation, ID: "123456789", Message: "123456789 admired 345678901 has no relation id"},
+		{Kind: model.IssueMissingRelation, ID: "danglingmeta1", Message: "the relation nosuchrel001 of the metadata is not in the store"},
+		{Kind: model.IssueMissingNode, ID: "danglingrel01", Message: "nodes not found : 999999999"},
+		{Kind: model.IssueMissingQuad, ID: "danglingrel01", Message: "the quad of the relation is not in the store"},
+	}, issues)
+
+	// ids written as literals are reported rather than read as IRIs
+	store = makeTestStore(t)
+	store.AddQuad(quad.Make(
+		quad.String(`{"subject":"<123456789>","predicate":"<copied>","object":"<345678901>","label":""}`),
+		model.RelationidPredicate, quad.String("r"), nil))
+	store.AddQuad(quad.Make(quad.String("nosuchrel002"), model.MetaidPredicate, quad.Raw("m"), nil))
+
+	issues, err = NewStatsService(store).Check(context.Background())
+	assert.NoError(err)
+	sort.Sort(byIssue(issues))
+	assert.Equal([]model.IntegrityIssue{
+		{Kind: model.IssueMissingRelation, ID: "m", Message: "the relation nosuchrel002 of the metadata is not in the store"},
+		{Kind: model.IssueMissingQuad, ID: "r", Message: "the quad of the relation is not in the store"},
+	}, issues)
+}
+
+// byIssue orders issues by ID and kind, as the quads of the store are not read in a fixed order
+type byIssue []model.IntegrityIssue
+
+func (issues byIssue) Len() int      { return len(issues) }
+func (issues byIssue) Swap(i, j int) { issues[i], issues[j] = issues[j], issues[i] }
+func (issues byIssue) Less(i, j int) bool {
+	if issues[i].ID != issues[j].ID {
+		return issues[i].ID < issues[j].ID
+	}
+	return issues[i].Kind < issues[j].Kind
+}
@@ -0,0 +1,258 @@
+package aceservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/model"
+)
+
+// TraversalDirection is the direction relations are followed from a node
+type TraversalDirection string
+
+const (
+	DirectionOut  TraversalDirection = "out"
+	DirectionIn   TraversalDirection = "in"
+	DirectionBoth TraversalDirection = "both"
+)
+
+// quadDirections will return the quad directions the node is found in when following relations in the direction d
+func (d TraversalDirection) quadDirections() ([]quad.Direction, error) {
+	switch d {
+	case DirectionOut:
+		return []quad.Direction{quad.Subject}, nil
+	case DirectionIn:
+		return []quad.Direction{quad.Object}, nil
+	case DirectionBoth:
+		return []quad.Direction{quad.Subject, quad.Object}, nil
+	}
+	return nil, fmt.Errorf("Unknown direction %s", d)
+}
+
+// TraversalService walks the relations between nodes in a graph store
+type TraversalService struct {
+	nodes     *NodeService
+	relations *RelationService
+}
+
+// NewTraversalService will return a TraversalService for the given store
+func NewTraversalService(store *cayley.Handle) *TraversalService {
+	return &TraversalService{
+		nodes:     NewNodeService(store),
+		relations: NewRelationService(store),
+	}
+}
+
+// Neighborhood will return the nodes and relations within depth hops of the node.  Relations are followed in the direction d,
+// and if types is not empty only relations of the given types are followed.  Nodes the caller may not read within the nodes
+// group, and relations the caller may not read within the relations group, are not followed.  ok is false if the node does not exist
+func (s *TraversalService) Neighborhood(ctx context.Context, nodeID string, depth int, d TraversalDirection, types []quad.IRI) (subgraph model.Graph, ok bool, err error) {
+	directions, err := d.quadDirections()
+	if err != nil {
+		return subgraph, false, err
+	}
+
+	start, found, err := s.node(quad.IRI(nodeID))
+	if err != nil || !found {
+		return subgraph, found, err
+	}
+	if err = authorize(ctx, GroupNodes, PermissionRead, start.Label); err != nil {
+		return subgraph, true, err
+	}
+
+	ids := s.relations.newRelationIDs()
+	visited := map[quad.IRI]bool{start.ID: true}
+	seenRelations := make(map[string]bool)
+	subgraph.Nodes = []model.Node{start}
+	subgraph.Relations = []model.Relation{}
+
+	frontier := []quad.IRI{start.ID}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []quad.IRI
+		for _, current := range frontier {
+			for _, direction := range directions {
+				for _, relation := range s.relations.nodeRelations(current, direction, types, ids) {
+					if !permitted(ctx, GroupRelations, PermissionRead, relation.Label) {
+						continue
+					}
+					key := quadKey(quad.Make(relation.SourceID, relation.Type, relation.TargetID, relation.Label))
+					if !seenRelations[key] {
+						seenRelations[key] = true
+						subgraph.Relations = append(subgraph.Relations, relation)
+					}
+
+					neighbor := relation.TargetID
+					if direction == quad.Object {
+						neighbor = relation.SourceID
+					}
+					if visited[neighbor] {
+						continue
+					}
+					visited[neighbor] = true
+					node, found, err := s.node(neighbor)
+					if err != nil {
+						return subgraph, true, err
+					}
+					if found && !permitted(ctx, GroupNodes, PermissionRead, node.Label) {
+						continue
+					}
+					if found {
+						subgraph.Nodes = append(subgraph.Nodes, node)
+					}
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+	return subgraph, true, nil
+}
+
+// node will return the node for an id.  found is false if there are no quads for the node
+func (s *TraversalService) node(nodeID quad.IRI) (node model.Node, found bool, err error) {
+	quadList := s.nodes.GetQuadsBySubject(string(nodeID))
+	if len(quadList) == 0 {
+		return node, false, nil
+	}
+	node, err = QuadListToNode(quadList)
+	return node, true, err
+}
+
+// readable will return true if the node exists and the caller may read it within the nodes group
+func (s *TraversalService) readable(ctx context.Context, nodeID quad.IRI) (bool, error) {
+	node, exists, err := s.node(nodeID)
+	if err != nil {
+		return false, err
+	}
+	return exists && permitted(ctx, GroupNodes, PermissionRead, node.Label), nil
+}
+
+// ShortestPath will return the relations along the shortest path from one node to another, following relations from source to target.
+// The search runs from both ends of the path and stops after maxDepth hops.  If types is not empty only relations of the given types are followed,
+// and only nodes and relations the caller may read are followed.  The caller must be granted the paths group, and as for Neighborhood
+// nodes are checked against the nodes group and relations against the relations group.
+// found is false if either node does not exist or there is no path between them.  An error reading a node is returned
+// rather than reported as no path
+func (s *TraversalService) ShortestPath(ctx context.Context, from string, to string, maxDepth int, types []quad.IRI) (path []model.Relation, found bool, err error) {
+	if err := AuthorizeGroup(ctx, GroupPaths, PermissionRead); err != nil {
+		return nil, false, err
+	}
+	source, target := quad.IRI(from), quad.IRI(to)
+	for _, nodeID := range []quad.IRI{source, target} {
+		if readable, err := s.readable(ctx, nodeID); err != nil || !readable {
+			return nil, false, err
+		}
+	}
+	if source == target {
+		return []model.Relation{}, true, nil
+	}
+
+	ids := s.relations.newRelationIDs()
+	forward := newSearch(source, quad.Subject)
+	backward := newSearch(target, quad.Object)
+
+	for hops := 0; hops < maxDepth && len(forward.frontier) > 0 && len(backward.frontier) > 0; hops++ {
+		expand, other := forward, backward
+		if len(backward.frontier) < len(forward.frontier) {
+			expand, other = backward, forward
+		}
+
+		reachedNodes, err := s.expand(ctx, expand, types, ids)
+		if err != nil {
+			return nil, false, err
+		}
+		var meeting quad.IRI
+		shortest := -1
+		for _, reached := range reachedNodes {
+			if depth, ok := other.depth[reached]; ok {
+				if length := expand.depth[reached] + depth; shortest < 0 || length < shortest {
+					shortest = length
+					meeting = reached
+				}
+			}
+		}
+		if shortest >= 0 {
+			return append(forward.pathTo(meeting), backward.pathTo(meeting)...), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// search is one side of a bidirectional breadth first search
+type search struct {
+	direction quad.Direction
+	frontier  []quad.IRI
+	depth     map[quad.IRI]int
+	parent    map[quad.IRI]model.Relation
+}
+
+// newSearch will return a search starting at the node.  direction is quad.Subject to follow relations from source to target,
+// quad.Object to follow them from target to source
+func newSearch(start quad.IRI, direction quad.Direction) *search {
+	return &search{
+		direction: direction,
+		frontier:  []quad.IRI{start},
+		depth:     map[quad.IRI]int{start: 0},
+		parent:    make(map[quad.IRI]model.Relation),
+	}
+}
+
+// expand will advance the search by one hop and return the nodes reached for the first time
+func (s *TraversalService) expand(ctx context.Context, side *search, types []quad.IRI, ids *relationIDs) ([]quad.IRI, error) {
+	var next []quad.IRI
+	_, restricted := PrincipalFromContext(ctx)
+	for _, current := range side.frontier {
+		for _, relation := range s.relations.nodeRelations(current, side.direction, types, ids) {
+			if !permitted(ctx, GroupRelations, PermissionRead, relation.Label) {
+				continue
+			}
+			neighbor := relation.TargetID
+			if side.direction == quad.Object {
+				neighbor = relation.SourceID
+			}
+			if _, visited := side.depth[neighbor]; visited {
+				continue
+			}
+			if restricted {
+				readable, err := s.readable(ctx, neighbor)
+				if err != nil {
+					return nil, err
+				}
+				if !readable {
+					continue
+				}
+			}
+			side.depth[neighbor] = side.depth[current] + 1
+			side.parent[neighbor] = relation
+			next = append(next, neighbor)
+		}
+	}
+	side.frontier = next
+	return next, nil
+}
+
+// pathTo will return the relations between the start of the search and the node, ordered from source to target
+func (side *search) pathTo(node quad.IRI) []model.Relation {
+	var path []model.Relation
+	for {
+		relation, ok := side.parent[node]
+		if !ok {
+			break
+		}
+		path = append(path, relation)
+		if side.direction == quad.Subject {
+			node = relation.SourceID
+		} else {
+			node = relation.TargetID
+		}
+	}
+	if side.direction == quad.Subject {
+		// the forward search walks back from the node to the source
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+	}
+	return path
+}
@@ -0,0 +1,264 @@
+package aceservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/gkontos/gasket/acemetrics"
+	"github.com/gkontos/gasket/model"
+)
+
+// TypeService reads and writes node types.  Each type is stored as a JSON document in a single quad
+type TypeService struct {
+	*QuadService
+}
+
+// NewTypeService will return a TypeService for the given store
+func NewTypeService(store *cayley.Handle) *TypeService {
+	return &TypeService{QuadService: NewQuadService(store)}
+}
+
+// TypeError indicates that a node or relation does not match the type of its node
+type TypeError struct {
+	Err        error
+	Message    string
+	Violations []model.Violation
+}
+
+func (e *TypeError) Error() string {
+	if e.Message != "" {
+		return e.Message + " " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// typeQuad will return the quad holding the definition of the type
+func typeQuad(nodeType model.NodeType) (quad.Quad, error) {
+	definition, err := json.Marshal(nodeType)
+	if err != nil {
+		return quad.Quad{}, err
+	}
+	return quad.Make(model.TypeID(nodeType.Name), model.TypeDefinitionPredicate, quad.String(definition), nil), nil
+}
+
+// typeQuads will return the definition quads stored for the type name
+func (s *TypeService) typeQuads(name string) []quad.Quad {
+	var quadList []quad.Quad
+	for _, q := range s.GetQuadsBySubject(string(model.TypeID(name))) {
+		if q.Predicate == model.TypeDefinitionPredicate {
+			quadList = append(quadList, q)
+		}
+	}
+	return quadList
+}
+
+// quadToType will read the type definition held in a quad
+func quadToType(q quad.Quad) (model.NodeType, error) {
+	var nodeType model.NodeType
+	err := json.Unmarshal([]byte(literalText(q.Object)), &nodeType)
+	return nodeType, err
+}
+
+// GetType will return the type with the given name.  false is returned if the type does not exist
+func (s *TypeService) GetType(ctx context.Context, name string) (model.NodeType, bool, error) {
+	if err := AuthorizeGroup(ctx, GroupTypes, PermissionRead); err != nil {
+		return model.NodeType{}, false, err
+	}
+	return s.getType(name)
+}
+
+// getType will return the type with the given name without checking the caller
+func (s *TypeService) getType(name string) (model.NodeType, bool, error) {
+	for _, q := range s.typeQuads(name) {
+		nodeType, err := quadToType(q)
+		if err != nil {
+			return nodeType, true, &DataStoreError{Message: "Unable to read type " + name, Err: err}
+		}
+		return nodeType, true, nil
+	}
+	return model.NodeType{}, false, nil
+}
+
+// ListTypes will return every type in the store ordered by name
+func (s *TypeService) ListTypes(ctx context.Context) ([]model.NodeType, error) {
+	if err := AuthorizeGroup(ctx, GroupTypes, PermissionRead); err != nil {
+		return nil, err
+	}
+	typesByName := make(map[string]model.NodeType)
+	var names []string
+
+	scan := acemetrics.StartScan("types")
+	defer scan.Done()
+	it := s.store.QuadIterator(quad.Predicate, s.store.ValueOf(model.TypeDefinitionPredicate))
+	defer it.Close()
+	for it.Next() {
+		scan.Quad()
+		nodeType, err := quadToType(s.store.Quad(it.Result()))
+		if err != nil {
+			return nil, &DataStoreError{Message: "Unable to read types", Err: err}
+		}
+		typesByName[nodeType.Name] = nodeType
+		names = append(names, nodeType.Name)
+	}
+	sort.Strings(names)
+
+	types := []model.NodeType{}
+	for _, name := range names {
+		types = append(types, typesByName[name])
+	}
+	return types, nil
+}
+
+// AddType will save a new type.  A ConflictError is returned if the type already exists
+func (s *TypeService) AddType(ctx context.Context, nodeType model.NodeType) error {
+	if err := AuthorizeGroup(ctx, GroupTypes, PermissionWrite); err != nil {
+		return err
+	}
+	if len(s.typeQuads(nodeType.Name)) > 0 {
+		return &ConflictError{Message: "Unable to add type " + nodeType.Name, Err: fmt.Errorf("type already exists")}
+	}
+	q, err := typeQuad(nodeType)
+	if err != nil {
+		return err
+	}
+	if err = s.store.AddQuad(q); err != nil {
+		return &DataStoreError{Message: "Error saving data", Err: err}
+	}
+	return nil
+}
+
+// UpdateType will replace the definition of a type.  Nodes already in the store are not checked against the new definition.
+// false is returned if the type does not exist
+func (s *TypeService) UpdateType(ctx context.Context, nodeType model.NodeType) (bool, error) {
+	if err := AuthorizeGroup(ctx, GroupTypes, PermissionWrite); err != nil {
+		return false, err
+	}
+	current := s.typeQuads(nodeType.Name)
+	if len(current) == 0 {
+		return false, nil
+	}
+	q, err := typeQuad(nodeType)
+	if err != nil {
+		return true, err
+	}
+	tx := cayley.NewTransaction()
+	for _, currentQuad := range current {
+		tx.RemoveQuad(currentQuad)
+	}
+	tx.AddQuad(q)
+	if err = s.applyTransaction(tx); err != nil {
+		return true, &DataStoreError{Message: "Error saving data", Err: err}
+	}
+	return true, nil
+}
+
+// DeleteType will remove a type.  Nodes of the type are kept, but are no longer checked.  false is returned if the type does not exist
+func (s *TypeService) DeleteType(ctx context.Context, name string) (bool, error) {
+	if err := AuthorizeGroup(ctx, GroupTypes, PermissionDelete); err != nil {
+		return false, err
+	}
+	current := s.typeQuads(name)
+	if len(current) == 0 {
+		return false, nil
+	}
+	tx := cayley.NewTransaction()
+	for _, q := range current {
+		tx.RemoveQuad(q)
+	}
+	if err := s.applyTransaction(tx); err != nil {
+		return true, &DataStoreError{Message: "Error deleting type " + name, Err: err}
+	}
+	return true, nil
+}
+
+// literalText will return the text of a value without the N-Quads quoting
+func literalText(value quad.Value) string {
+	switch v := value.(type) {
+	case quad.Raw:
+		return string(v)
+	case quad.String:
+		return string(v)
+	case quad.IRI:
+		return string(v)
+	}
+	return quad.StringOf(value)
+}
+
+// nodeType will return the type of a node with the rdf:type value.  false is returned if the value is nil or no type is defined
+func (s *TypeService) nodeType(value quad.Value) (model.NodeType, bool, error) {
+	if value == nil {
+		return model.NodeType{}, false, nil
+	}
+	return s.getType(literalText(value))
+}
+
+// storedNodeType will return the rdf:type value stored for the node
+func (s *TypeService) storedNodeType(nodeID quad.IRI) quad.Value {
+	for _, q := range s.GetQuadsBySubject(string(nodeID)) {
+		if q.Predicate == model.TypePredicate {
+			return q.Object
+		}
+	}
+	return nil
+}
+
+// CheckNode will check the properties of a node against the type named by its rdf:type property.  When current
+// is not nil the node is an update of current, only the properties sent are checked against the type, and the
+// required properties are checked against the properties of the updated node
+func (s *TypeService) CheckNode(node model.Node, current *model.Node) error {
+	typeValue := node.Properties[string(model.TypePredicate)]
+	name := node.Name
+	properties := node.Properties
+	if current != nil {
+		if typeValue == nil {
+			typeValue = current.Properties[string(model.TypePredicate)]
+		}
+		if name == "" {
+			name = current.Name
+		}
+		properties = make(map[string]quad.Value)
+		for key, value := range current.Properties {
+			properties[key] = value
+		}
+		for key, value := range node.Properties {
+			properties[key] = value
+		}
+	}
+
+	nodeType, found, err := s.nodeType(typeValue)
+	if err != nil || !found {
+		return err
+	}
+	violations := nodeType.ValidateProperties(node.Properties)
+	violations = append(violations, nodeType.MissingProperties(name, properties)...)
+	if len(violations) > 0 {
+		return &TypeError{
+			Message:    "Node does not match type " + nodeType.Name,
+			Err:        fmt.Errorf("%d type violations", len(violations)),
+			Violations: violations,
+		}
+	}
+	return nil
+}
+
+// CheckRelation will check that the relation type is allowed by the type of the source node
+func (s *TypeService) CheckRelation(relation model.Relation) error {
+	nodeType, found, err := s.nodeType(s.storedNodeType(relation.SourceID))
+	if err != nil || !found {
+		return err
+	}
+	if !nodeType.AllowsRelation(string(relation.Type)) {
+		return &TypeError{
+			Message: "Relation is not allowed by type " + nodeType.Name,
+			Err:     fmt.Errorf("relation type %s is not allowed", relation.Type),
+			Violations: []model.Violation{
+				{Pointer: "/type", Message: "is not an allowed relation of type " + nodeType.Name},
+			},
+		}
+	}
+	return nil
+}
@@ -12,3 +12,16 @@ func (e *RequestParseError) Error() string {
 	}
 	return e.Err.Error()
 }
+
+//MediaTypeError is an error type indicating that the request body is not in a supported format
+type MediaTypeError struct {
+	Err     error
+	Message string
+}
+
+func (e *MediaTypeError) Error() string {
+	if e.Message != "" {
+		return e.Message + " " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
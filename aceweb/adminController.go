@@ -0,0 +1,63 @@
+package aceweb
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/gkontos/gasket/acelog"
+	service "github.com/gkontos/gasket/aceservice"
+	"github.com/gkontos/gasket/model"
+)
+
+// LogLevelGet will return the levels of the application and access logs
+// router.HandleFunc("/admin/log-level", LogLevelGet).Methods("GET")
+func (s *Server) LogLevelGet(w http.ResponseWriter, r *http.Request) {
+	if err := authorizeAdmin(r, service.PermissionRead); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	ReturnBodyJSON(w, log.GetLevels(), http.StatusOK)
+}
+
+// LogLevelUpdate will change the levels of the application and access logs.  A level which is not sent is unchanged
+// router.HandleFunc("/admin/log-level", LogLevelUpdate).Methods("PUT")
+func (s *Server) LogLevelUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := authorizeAdmin(r, service.PermissionWrite); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+
+	var levels log.Levels
+	if err := ParseJsonRequest(r, &levels); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if levels == (log.Levels{}) {
+		ReturnErrorJSON(w, &ValidationError{
+			Message:    "Invalid request",
+			Err:        fmt.Errorf("no level was sent"),
+			Violations: []model.Violation{{Pointer: "", Message: "must set application or access"}},
+		})
+		return
+	}
+	if err := log.SetLevels(levels); err != nil {
+		ReturnErrorJSON(w, &ValidationError{Message: "Invalid level", Err: err})
+		return
+	}
+	log.WithContext(r.Context()).WithFields(log.Fields{"application": levels.Application, "access": levels.Access}).Warn("Log levels changed")
+	ReturnBodyJSON(w, log.GetLevels(), http.StatusOK)
+}
+
+// authorizeAdmin will return an AuthorizationError unless the request was authenticated with a bearer token and the
+// caller is granted the permission within the admin group.  The admin routes are refused when auth.enabled is not set
+func authorizeAdmin(r *http.Request, permission service.Permission) error {
+	if _, ok := RequestClaims(r); !ok {
+		err := &service.AuthorizationError{
+			Message: "Forbidden",
+			Err:     fmt.Errorf("%s requires an authenticated request, set auth.enabled", r.URL.Path),
+		}
+		log.WithContext(r.Context()).Warn(err)
+		return err
+	}
+	return service.AuthorizeGroup(r.Context(), service.GroupAdmin, permission)
+}
@@ -0,0 +1,73 @@
+package aceweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	log "github.com/gkontos/gasket/acelog"
+	service "github.com/gkontos/gasket/aceservice"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevelUpdateController(t *testing.T) {
+	initial := log.GetLevels()
+	defer log.SetLevels(log.Levels{Application: initial.Application})
+
+	tests := []internal.ControllerTestCase{
+		{
+			Description:    "Change application level",
+			Url:            "/admin/log-level",
+			Body:           []byte(`{"application":"warning"}`),
+			ExpectedObject: &log.Levels{Application: "warning", Access: initial.Access},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Invalid level",
+			Url:            "/admin/log-level",
+			Body:           []byte(`{"application":"verbose"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		}, {
+			Description:    "No level",
+			Url:            "/admin/log-level",
+			Body:           []byte(`{}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "PUT", asAuthenticated(srv.LogLevelUpdate),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			if expected, ok := tc.ExpectedObject.(*log.Levels); ok {
+				var levels log.Levels
+				assert.NoError(t, json.Unmarshal(body, &levels), tc.Description)
+				assert.Equal(t, *expected, levels, tc.Description)
+			}
+		})
+	assert.Equal(t, "warning", log.GetLevels().Application, "invalid level must not change the level")
+
+	editor := &service.Principal{Subject: "gasket", Roles: []service.Role{testEditor}}
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Group not granted", Url: "/admin/log-level", Body: []byte(`{"application":"debug"}`), ExpectedCode: http.StatusForbidden},
+	}, "PUT", asPrincipal(editor, asAuthenticated(srv.LogLevelUpdate)), func(t *testing.T, body []byte, tc internal.ControllerTestCase) {})
+	assert.Equal(t, "warning", log.GetLevels().Application, "forbidden request must not change the level")
+
+	// without an authenticator the admin routes are refused
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Not authenticated", Url: "/admin/log-level", Body: []byte(`{"application":"debug"}`), ExpectedCode: http.StatusForbidden},
+	}, "PUT", http.HandlerFunc(srv.LogLevelUpdate), func(t *testing.T, body []byte, tc internal.ControllerTestCase) {})
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Not authenticated", Url: "/admin/log-level", ExpectedCode: http.StatusForbidden},
+	}, "GET", http.HandlerFunc(srv.LogLevelGet), func(t *testing.T, body []byte, tc internal.ControllerTestCase) {})
+	assert.Equal(t, "warning", log.GetLevels().Application, "unauthenticated request must not change the level")
+}
+
+// asAuthenticated will call the handler as though the request carried a valid bearer token
+func asAuthenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, withClaims(r, jwt.MapClaims{"sub": "gasket"}))
+	}
+}
@@ -0,0 +1,183 @@
+package aceweb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	log "github.com/gkontos/gasket/acelog"
+	service "github.com/gkontos/gasket/aceservice"
+	"github.com/spf13/viper"
+)
+
+// AuthenticationError is an error type indicating that the request does not carry a valid bearer token
+type AuthenticationError struct {
+	Err     error
+	Message string
+}
+
+func (e *AuthenticationError) Error() string {
+	if e.Message != "" {
+		return e.Message + " " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// Authenticator validates the bearer tokens of requests.  Tokens are either signed with HS256 using a shared secret,
+// or signed with RS256 and checked against a configured public key.  When roles are configured, the roles claim of
+// each token names the roles granted to the caller
+type Authenticator struct {
+	method     jwt.SigningMethod
+	key        interface{}
+	roles      map[string]service.Role
+	rolesClaim string
+}
+
+// DefaultRolesClaim is the token claim listing the roles of the caller when auth.roles_claim is not set
+const DefaultRolesClaim = "roles"
+
+// NewAuthenticator will return an Authenticator for the [auth] block of the configuration.  nil is returned when
+// auth.enabled is not set, in which case requests are not authenticated
+func NewAuthenticator(v *viper.Viper) (*Authenticator, error) {
+	if !v.GetBool("auth.enabled") {
+		return nil, nil
+	}
+	a, err := newTokenAuthenticator(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = v.UnmarshalKey("auth.roles", &a.roles); err != nil {
+		return nil, fmt.Errorf("unable to read auth.roles : %v", err)
+	}
+	a.rolesClaim = v.GetString("auth.roles_claim")
+	if a.rolesClaim == "" {
+		a.rolesClaim = DefaultRolesClaim
+	}
+	return a, nil
+}
+
+// newTokenAuthenticator will return an Authenticator checking tokens with the configured algorithm and key
+func newTokenAuthenticator(v *viper.Viper) (*Authenticator, error) {
+	switch algorithm := v.GetString("auth.algorithm"); algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		secret := v.GetString("auth.hmac_secret")
+		if secret == "" {
+			return nil, fmt.Errorf("auth.hmac_secret is required for %s", jwt.SigningMethodHS256.Alg())
+		}
+		return &Authenticator{method: jwt.SigningMethodHS256, key: []byte(secret)}, nil
+	case jwt.SigningMethodRS256.Alg():
+		keyFile := v.GetString("auth.rsa_public_key_file")
+		if keyFile == "" {
+			return nil, fmt.Errorf("auth.rsa_public_key_file is required for %s", algorithm)
+		}
+		pem, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s : %v", keyFile, err)
+		}
+		return &Authenticator{method: jwt.SigningMethodRS256, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth.algorithm %s", algorithm)
+	}
+}
+
+type claimsKey struct{}
+
+// RequestClaims will return the claims of the bearer token authenticating the request
+func RequestClaims(r *http.Request) (jwt.MapClaims, bool) {
+	claims, ok := r.Context().Value(claimsKey{}).(jwt.MapClaims)
+	return claims, ok
+}
+
+// withClaims will return a copy of the request carrying the claims
+func withClaims(r *http.Request, claims jwt.MapClaims) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims))
+}
+
+// Authenticate will return the claims of the bearer token within the request
+func (a *Authenticator) Authenticate(r *http.Request) (jwt.MapClaims, error) {
+	tokenString, err := request.AuthorizationHeaderExtractor.ExtractToken(r)
+	if err != nil {
+		return nil, &AuthenticationError{Message: "Missing bearer token", Err: err}
+	}
+
+	parser := jwt.Parser{ValidMethods: []string{a.method.Alg()}}
+	claims := jwt.MapClaims{}
+	if _, err = parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.key, nil
+	}); err != nil {
+		return nil, &AuthenticationError{Message: "Invalid bearer token", Err: err}
+	}
+	return claims, nil
+}
+
+// Principal will return the caller identified by the claims, granted the configured roles named by the roles claim.
+// nil is returned when no roles are configured, in which case requests are not authorized
+func (a *Authenticator) Principal(claims jwt.MapClaims) *service.Principal {
+	if len(a.roles) == 0 {
+		return nil
+	}
+	principal := &service.Principal{}
+	principal.Subject, _ = claims["sub"].(string)
+
+	var names []string
+	switch value := claims[a.rolesClaim].(type) {
+	case string:
+		names = strings.Fields(value)
+	case []interface{}:
+		for _, name := range value {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	for _, name := range names {
+		// role names are read from the configuration in lower case
+		if role, ok := a.roles[strings.ToLower(name)]; ok {
+			principal.Roles = append(principal.Roles, role)
+		} else {
+			log.Debug("Ignoring unknown role ", name, " of ", principal.Subject)
+		}
+	}
+	return principal
+}
+
+// AuthHandler will return a handler which rejects requests without a valid bearer token.  The claims of the token
+// are available to the wrapped handler from RequestClaims, and when roles are configured the request context carries
+// the principal the services authorize against.  h is returned unchanged when a is nil
+func AuthHandler(a *Authenticator, h http.Handler) http.Handler {
+	if a == nil {
+		return h
+	}
+	return authHandler{handler: h, auth: a}
+}
+
+type authHandler struct {
+	handler http.Handler
+	auth    *Authenticator
+}
+
+func (h authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.auth.Authenticate(r)
+	if err != nil {
+		log.Debug(err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		ReturnErrorJSON(w, err)
+		return
+	}
+	r = withClaims(r, claims)
+	if subject, ok := claims["sub"].(string); ok {
+		r = r.WithContext(log.ContextWithFields(r.Context(), log.Fields{"user": subject}))
+	}
+	if principal := h.auth.Principal(claims); principal != nil {
+		r = r.WithContext(service.WithPrincipal(r.Context(), principal))
+	}
+	h.handler.ServeHTTP(w, r)
+}
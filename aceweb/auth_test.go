@@ -0,0 +1,251 @@
+package aceweb
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cayleygraph/cayley/quad"
+	jwt "github.com/dgrijalva/jwt-go"
+	service "github.com/gkontos/gasket/aceservice"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gkontos/gasket/model"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// claimsHandler will return the subject claim of the request
+var claimsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	claims, _ := RequestClaims(r)
+	ReturnBodyJSON(w, claims["sub"], http.StatusOK)
+})
+
+func authRequest(token string) *http.Request {
+	req, _ := http.NewRequest("GET", "/nodes", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	assert.NoError(t, err)
+	return token
+}
+
+func TestAuthHandlerHS256(t *testing.T) {
+	assert := assert.New(t)
+	v := viper.New()
+	v.Set("auth.enabled", true)
+	v.Set("auth.algorithm", "HS256")
+	v.Set("auth.hmac_secret", "secret")
+	authenticator, err := NewAuthenticator(v)
+	assert.NoError(err)
+	handler := AuthHandler(authenticator, claimsHandler)
+
+	tests := []struct {
+		Description  string
+		Token        string
+		ExpectedCode int
+	}{
+		{"Valid token", signToken(t, jwt.SigningMethodHS256, []byte("secret"), jwt.MapClaims{"sub": "gasket"}), http.StatusOK},
+		{"Missing token", "", http.StatusUnauthorized},
+		{"Wrong secret", signToken(t, jwt.SigningMethodHS256, []byte("guess"), jwt.MapClaims{"sub": "gasket"}), http.StatusUnauthorized},
+		{"Expired token", signToken(t, jwt.SigningMethodHS256, []byte("secret"),
+			jwt.MapClaims{"sub": "gasket", "exp": time.Now().Add(-time.Hour).Unix()}), http.StatusUnauthorized},
+		{"Unsigned token", signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"sub": "gasket"}), http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, authRequest(tc.Token))
+		assert.Equal(tc.ExpectedCode, resp.Code, tc.Description)
+		if tc.ExpectedCode == http.StatusOK {
+			var subject string
+			assert.NoError(json.Unmarshal(resp.Body.Bytes(), &subject), tc.Description)
+			assert.Equal("gasket", subject, tc.Description+" -claims")
+		} else {
+			var body jsonErr
+			assert.NoError(json.Unmarshal(resp.Body.Bytes(), &body), tc.Description)
+			assert.Equal(http.StatusUnauthorized, body.Code, tc.Description+" -body")
+			assert.Equal("Bearer", resp.Header().Get("WWW-Authenticate"), tc.Description)
+		}
+	}
+}
+
+func TestAuthHandlerRS256(t *testing.T) {
+	assert := assert.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(err)
+
+	keyFile, err := ioutil.TempFile("", "gasket-auth")
+	assert.NoError(err)
+	defer os.Remove(keyFile.Name())
+	assert.NoError(pem.Encode(keyFile, &pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+	keyFile.Close()
+
+	v := viper.New()
+	v.Set("auth.enabled", true)
+	v.Set("auth.algorithm", "RS256")
+	v.Set("auth.rsa_public_key_file", keyFile.Name())
+	authenticator, err := NewAuthenticator(v)
+	assert.NoError(err)
+	handler := AuthHandler(authenticator, claimsHandler)
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, authRequest(signToken(t, jwt.SigningMethodRS256, key, jwt.MapClaims{"sub": "gasket"})))
+	assert.Equal(http.StatusOK, resp.Code, "Valid token")
+
+	// a token signed with the public key as an HS256 secret must not be accepted
+	pemKey, _ := ioutil.ReadFile(keyFile.Name())
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, authRequest(signToken(t, jwt.SigningMethodHS256, pemKey, jwt.MapClaims{"sub": "gasket"})))
+	assert.Equal(http.StatusUnauthorized, resp.Code, "Algorithm substitution")
+}
+
+func TestAuthDisabled(t *testing.T) {
+	authenticator, err := NewAuthenticator(viper.New())
+	assert.NoError(t, err)
+	assert.Nil(t, authenticator)
+
+	resp := httptest.NewRecorder()
+	AuthHandler(authenticator, claimsHandler).ServeHTTP(resp, authRequest(""))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+// testEditor may use the node and relation endpoints on the quads labelled test
+var testEditor = service.Role{
+	Groups: []string{service.GroupNodes, service.GroupRelations},
+	Read:   []string{"test"},
+	Write:  []string{"test"},
+	Delete: []string{"test"},
+}
+
+// asPrincipal will return a handler serving the request with the principal in its context
+func asPrincipal(p *service.Principal, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r.WithContext(service.WithPrincipal(r.Context(), p)))
+	}
+}
+
+func TestAuthHandlerPrincipal(t *testing.T) {
+	assert := assert.New(t)
+	v := viper.New()
+	v.Set("auth.enabled", true)
+	v.Set("auth.hmac_secret", "secret")
+	v.Set("auth.roles", map[string]interface{}{
+		"test-editor": map[string]interface{}{"groups": []string{"nodes"}, "read": []string{"test"}},
+	})
+	authenticator, err := NewAuthenticator(v)
+	assert.NoError(err)
+
+	var principal *service.Principal
+	handler := AuthHandler(authenticator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = service.PrincipalFromContext(r.Context())
+	}))
+	token := signToken(t, jwt.SigningMethodHS256, []byte("secret"),
+		jwt.MapClaims{"sub": "gasket", "roles": []string{"test-editor", "unknown"}})
+	handler.ServeHTTP(httptest.NewRecorder(), authRequest(token))
+
+	if assert.NotNil(principal) {
+		assert.Equal("gasket", principal.Subject)
+		assert.Len(principal.Roles, 1)
+		assert.True(principal.Allows(service.GroupNodes, service.PermissionRead, "test"))
+		assert.False(principal.Allows(service.GroupNodes, service.PermissionRead, "other"))
+		assert.False(principal.Allows(service.GroupNodes, service.PermissionWrite, "test"))
+	}
+}
+
+func TestLabelAuthorization(t *testing.T) {
+	srv := NewServer(internal.MakeTestStore(t))
+	otherNodes, err := srv.nodes.AddNode(context.Background(), model.Node{Name: "other node", Label: quad.String("other")})
+	assert.NoError(t, err)
+	otherID := string(otherNodes[0].Subject.(quad.IRI))
+	editor := &service.Principal{Subject: "gasket", Roles: []service.Role{testEditor}}
+
+	checkForbidden := func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+		if tc.ExpectedCode == http.StatusForbidden {
+			var response jsonErr
+			assert.NoError(t, json.Unmarshal(body, &response), tc.Description)
+			assert.Equal(t, http.StatusForbidden, response.Code, tc.Description)
+		}
+	}
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Read test label", RouteUrl: "/nodes/{id}", Url: "/nodes/123456789", ExpectedCode: http.StatusOK},
+		{Description: "Read other label", RouteUrl: "/nodes/{id}", Url: "/nodes/" + otherID, ExpectedCode: http.StatusForbidden},
+	}, "GET", asPrincipal(editor, srv.NodeGet), checkForbidden)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Create with test label", Url: "/nodes", Body: []byte(`{"name":"test node", "label":"test"}`), ExpectedCode: http.StatusCreated},
+		{Description: "Create with other label", Url: "/nodes", Body: []byte(`{"name":"other node", "label":"other"}`), ExpectedCode: http.StatusForbidden},
+		{Description: "Create without label", Url: "/nodes", Body: []byte(`{"name":"unlabelled node"}`), ExpectedCode: http.StatusForbidden},
+	}, "POST", asPrincipal(editor, srv.NodeCreate), checkForbidden)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Update test label", RouteUrl: "/nodes/{id}", Url: "/nodes/123456789", Body: []byte(`{"color":"blue"}`), ExpectedCode: http.StatusOK},
+		{Description: "Update other label", RouteUrl: "/nodes/{id}", Url: "/nodes/" + otherID, Body: []byte(`{"color":"blue"}`), ExpectedCode: http.StatusForbidden},
+		{Description: "Move to other label", RouteUrl: "/nodes/{id}", Url: "/nodes/123456789", Body: []byte(`{"label":"other"}`), ExpectedCode: http.StatusForbidden},
+	}, "PUT", asPrincipal(editor, srv.NodeUpdate), checkForbidden)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Delete other label", RouteUrl: "/nodes/{id}", Url: "/nodes/" + otherID, ExpectedCode: http.StatusForbidden},
+	}, "DELETE", asPrincipal(editor, srv.NodeDelete), checkForbidden)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "List only test label", Url: "/nodes", ExpectedCode: http.StatusOK},
+	}, "GET", asPrincipal(editor, srv.NodeList), func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+		var page model.NodePage
+		assert.NoError(t, json.Unmarshal(body, &page), tc.Description)
+		for _, node := range page.Nodes {
+			assert.Equal(t, quad.String("test"), node.Label, tc.Description+" "+string(node.ID))
+		}
+		assert.Len(t, page.Nodes, 4, tc.Description)
+	})
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Quads of test label", RouteUrl: "/nodes/{id}/relationships", Url: "/nodes/123456789/relationships", ExpectedCode: http.StatusOK},
+		{Description: "Quads of other label", RouteUrl: "/nodes/{id}/relationships", Url: "/nodes/" + otherID + "/relationships", ExpectedCode: http.StatusForbidden},
+	}, "GET", asPrincipal(editor, srv.NodeGetRelationships), func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+		checkForbidden(t, body, tc)
+		if tc.ExpectedCode == http.StatusOK {
+			var quads []quad.Quad
+			assert.NoError(t, json.Unmarshal(body, &quads), tc.Description)
+			assert.NotEmpty(t, quads, tc.Description)
+			for _, q := range quads {
+				if assert.NotNil(t, q.Label, tc.Description) {
+					assert.Equal(t, quad.String("test").String(), q.Label.String(), tc.Description)
+				}
+			}
+		}
+	})
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Neighborhood follows readable relations", RouteUrl: "/nodes/{id}/neighborhood", Url: "/nodes/123456789/neighborhood", ExpectedCode: http.StatusOK},
+	}, "GET", asPrincipal(editor, srv.NodeNeighborhood), func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+		var subgraph model.Graph
+		assert.NoError(t, json.Unmarshal(body, &subgraph), tc.Description)
+		// the relations of the test store are unlabelled, which the editor may not read
+		assert.Empty(t, subgraph.Relations, tc.Description)
+		assert.Len(t, subgraph.Nodes, 1, tc.Description)
+	})
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Paths group not granted", RouteUrl: "/paths", Url: "/paths?from=123456789&to=234567890", ExpectedCode: http.StatusForbidden},
+	}, "GET", asPrincipal(editor, srv.PathGet), checkForbidden)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Group not granted", Url: "/types", ExpectedCode: http.StatusForbidden},
+	}, "GET", asPrincipal(editor, srv.TypeList), checkForbidden)
+}
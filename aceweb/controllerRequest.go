@@ -1,13 +1,20 @@
 package aceweb
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
 
 	"io"
 	"io/ioutil"
 	"net/http"
 
+	"github.com/cayleygraph/cayley/quad"
 	log "github.com/gkontos/gasket/acelog"
+	service "github.com/gkontos/gasket/aceservice"
+	"github.com/gkontos/gasket/model"
 )
 
 // ParseJsonRequest will parse the request body and return objects in the type of val interface{}
@@ -15,13 +22,40 @@ func ParseJsonRequest(r *http.Request, val interface{}) error {
 	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 
 	if err != nil {
-		log.Error(err)
+		log.WithContext(r.Context()).Error(err)
 		return &RequestParseError{Message: "Unable to read message body", Err: err}
 	}
 	defer r.Body.Close()
 	err = json.Unmarshal(body, &val)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(r.Context()).Error(err)
+		return &RequestParseError{Message: "Unable to parse request", Err: err}
+	}
+	return nil
+}
+
+// ParseValidJsonRequest will check the request body against the schema before parsing it into val.  A ValidationError
+// listing every violation is returned if the body does not match the schema
+func ParseValidJsonRequest(r *http.Request, schema model.InputValidation, val interface{}) error {
+	body, err := GetRequestBody(r)
+	if err != nil {
+		log.WithContext(r.Context()).Error(err)
+		return err
+	}
+	var document interface{}
+	if err = json.Unmarshal(body, &document); err != nil {
+		log.WithContext(r.Context()).Error(err)
+		return &RequestParseError{Message: "Unable to parse request", Err: err}
+	}
+	if violations := schema.Validate(document); len(violations) > 0 {
+		return &ValidationError{
+			Message:    "Invalid request",
+			Err:        fmt.Errorf("%d schema violations", len(violations)),
+			Violations: violations,
+		}
+	}
+	if err = json.Unmarshal(body, val); err != nil {
+		log.WithContext(r.Context()).Error(err)
 		return &RequestParseError{Message: "Unable to parse request", Err: err}
 	}
 	return nil
@@ -40,3 +74,118 @@ func GetRequestBody(r *http.Request) ([]byte, error) {
 
 	return body, nil
 }
+
+// GetBoolParameter will return the value of a boolean query parameter, or defaultValue when it is not set
+func GetBoolParameter(r *http.Request, name string, defaultValue bool) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, &RequestParseError{Message: "Invalid " + name, Err: err}
+	}
+	return b, nil
+}
+
+// DefaultPageLimit is the number of items returned by a listing when no limit is requested
+const DefaultPageLimit = 50
+
+// MaxPageLimit is the largest number of items that may be requested in a single page
+const MaxPageLimit = 500
+
+// GetPageParameters will return the limit and the decoded cursor from the query parameters of a listing request
+func GetPageParameters(r *http.Request) (int, string, error) {
+	limit := DefaultPageLimit
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, "", &RequestParseError{Message: "Unable to parse limit", Err: err}
+		}
+		if parsed < 1 || parsed > MaxPageLimit {
+			return 0, "", &RequestParseError{Message: "Invalid limit", Err: fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)}
+		}
+		limit = parsed
+	}
+
+	var after string
+	if value := query.Get("cursor"); value != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return 0, "", &RequestParseError{Message: "Unable to parse cursor", Err: err}
+		}
+		after = string(decoded)
+	}
+	return limit, after, nil
+}
+
+// EncodeCursor will return an opaque cursor for the id of the last item in a page
+func EncodeCursor(ID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(ID))
+}
+
+// wherePattern matches filter query parameters of the form where[key] and where[key][operator]
+var wherePattern = regexp.MustCompile(`^where\[([^\]]+)\](?:\[(\w+)\])?$`)
+
+// GetPropertyFilters will return the property filters from the query parameters of a search request.
+// ie. where[color]=orange, where[name][prefix]=Shim, where[amount][gte]=10
+func GetPropertyFilters(r *http.Request) ([]service.PropertyFilter, error) {
+	var filters []service.PropertyFilter
+	for param, values := range r.URL.Query() {
+		match := wherePattern.FindStringSubmatch(param)
+		if match == nil {
+			continue
+		}
+		op := service.FilterEqual
+		if match[2] != "" {
+			op = service.FilterOperator(match[2])
+		}
+		for _, value := range values {
+			filter, err := service.NewPropertyFilter(match[1], op, value)
+			if err != nil {
+				return nil, &RequestParseError{Message: "Invalid filter " + param, Err: err}
+			}
+			filters = append(filters, filter)
+		}
+	}
+	return filters, nil
+}
+
+// MaxTraversalDepth is the largest number of hops that may be requested for a traversal
+const MaxTraversalDepth = 10
+
+// GetTraversalParameters will return the depth, direction and relation types from the query parameters of a traversal request.
+// depth defaults to 1 and direction to both
+func GetTraversalParameters(r *http.Request) (int, service.TraversalDirection, []quad.IRI, error) {
+	query := r.URL.Query()
+
+	depth := 1
+	if value := query.Get("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, "", nil, &RequestParseError{Message: "Unable to parse depth", Err: err}
+		}
+		if parsed < 1 || parsed > MaxTraversalDepth {
+			return 0, "", nil, &RequestParseError{Message: "Invalid depth", Err: fmt.Errorf("depth must be between 1 and %d", MaxTraversalDepth)}
+		}
+		depth = parsed
+	}
+
+	direction := service.DirectionBoth
+	if value := query.Get("direction"); value != "" {
+		direction = service.TraversalDirection(value)
+		switch direction {
+		case service.DirectionIn, service.DirectionOut, service.DirectionBoth:
+		default:
+			return 0, "", nil, &RequestParseError{Message: "Invalid direction", Err: fmt.Errorf("direction must be in, out or both")}
+		}
+	}
+
+	var types []quad.IRI
+	for _, value := range query["type"] {
+		types = append(types, quad.IRI(value))
+	}
+	return depth, direction, types, nil
+}
@@ -6,16 +6,21 @@ import (
 
 	log "github.com/gkontos/gasket/acelog"
 	service "github.com/gkontos/gasket/aceservice"
+	"github.com/gkontos/gasket/model"
 )
 
 type jsonErr struct {
-	Code int    `json:"code"`
-	Text string `json:"text"`
+	Code       int               `json:"code"`
+	Text       string            `json:"text"`
+	Violations []model.Violation `json:"violations,omitempty"`
+	Missing    []string          `json:"missing,omitempty"`
 }
 
+// ValidationError indicates that the request does not match the expected input.  Violations lists each failure of a schema
 type ValidationError struct {
-	Err     error
-	Message string
+	Err        error
+	Message    string
+	Violations []model.Violation
 }
 
 func (e *ValidationError) Error() string {
@@ -36,6 +41,18 @@ func ReturnErrorJSON(w http.ResponseWriter, err error) {
 			httpStatus = http.StatusBadRequest
 		case *ValidationError:
 			httpStatus = http.StatusBadRequest
+		case *AuthenticationError:
+			httpStatus = http.StatusUnauthorized
+		case *service.AuthorizationError:
+			httpStatus = http.StatusForbidden
+		case *MediaTypeError:
+			httpStatus = http.StatusUnsupportedMediaType
+		case *service.MissingReferenceError:
+			httpStatus = http.StatusUnprocessableEntity
+		case *service.TypeError:
+			httpStatus = http.StatusUnprocessableEntity
+		case *service.ConflictError:
+			httpStatus = http.StatusConflict
 		case *service.DataStoreError:
 			httpStatus = http.StatusInternalServerError
 		default:
@@ -43,7 +60,23 @@ func ReturnErrorJSON(w http.ResponseWriter, err error) {
 		}
 	}
 	w.WriteHeader(httpStatus)
-	if encodeErr := json.NewEncoder(w).Encode(err.Error()); encodeErr != nil {
+	// errors with details are returned as a jsonErr, others as the error text
+	var body interface{} = err.Error()
+	switch detailErr := err.(type) {
+	case *ValidationError:
+		if len(detailErr.Violations) > 0 {
+			body = jsonErr{Code: httpStatus, Text: detailErr.Error(), Violations: detailErr.Violations}
+		}
+	case *AuthenticationError:
+		body = jsonErr{Code: httpStatus, Text: detailErr.Error()}
+	case *service.AuthorizationError:
+		body = jsonErr{Code: httpStatus, Text: detailErr.Error()}
+	case *service.TypeError:
+		body = jsonErr{Code: httpStatus, Text: detailErr.Error(), Violations: detailErr.Violations}
+	case *service.MissingReferenceError:
+		body = jsonErr{Code: httpStatus, Text: detailErr.Error(), Missing: detailErr.Missing}
+	}
+	if encodeErr := json.NewEncoder(w).Encode(body); encodeErr != nil {
 		log.Error(encodeErr)
 	}
 }
@@ -0,0 +1,54 @@
+package aceweb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/gkontos/gasket/acelog"
+)
+
+// JSONLDMediaType is the content type of a JSON-LD document
+const JSONLDMediaType = "application/ld+json"
+
+// exportFormats maps the format query parameter to the content type of the export
+var exportFormats = map[string]string{
+	"nquads": NQuadsMediaType,
+	"jsonld": JSONLDMediaType,
+	"json":   "application/json",
+}
+
+// Export will write the contents of the store in the requested format.  The format query parameter is one of
+// nquads (default), jsonld or json, and the optional label query parameter restricts the export to a single label.
+// The export is streamed, and is only cut off when it takes longer than server.export_write_timeout
+// router.HandleFunc("/export", Export).Methods("GET")
+func (s *Server) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "nquads"
+	}
+	contentType, ok := exportFormats[format]
+	if !ok {
+		ReturnErrorJSON(w, &RequestParseError{Message: "Invalid format", Err: fmt.Errorf("format must be one of nquads, jsonld or json")})
+		return
+	}
+	label := r.URL.Query().Get("label")
+
+	var export func(ctx context.Context, w io.Writer, label string) error
+	switch format {
+	case "jsonld":
+		export = s.exporter.ExportJSONLD
+	case "json":
+		export = s.exporter.ExportGraph
+	default:
+		export = s.exporter.ExportNQuads
+	}
+
+	w.Header().Set("Content-Type", contentType+"; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	// the response is streamed, so an error after this point can only be logged
+	if err := export(r.Context(), w, label); err != nil {
+		log.WithContext(r.Context()).Error("Export failed : ", err)
+	}
+}
@@ -0,0 +1,95 @@
+package aceweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cayleygraph/cayley/quad/cquads"
+	log "github.com/gkontos/gasket/acelog"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type exportCounts struct {
+	nodes, relations, metadata int
+}
+
+func TestExportController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description:    "N-Quads",
+			Url:            "/export",
+			RouteUrl:       "/export",
+			ExpectedObject: 34,
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "N-Quads by label",
+			Url:            "/export?format=nquads&label=test",
+			RouteUrl:       "/export",
+			ExpectedObject: 27,
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "JSON-LD",
+			Url:            "/export?format=jsonld",
+			RouteUrl:       "/export",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "JSON",
+			Url:            "/export?format=json",
+			RouteUrl:       "/export",
+			ExpectedObject: exportCounts{nodes: 3, relations: 2, metadata: 3},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "JSON by label",
+			Url:            "/export?format=json&label=test",
+			RouteUrl:       "/export",
+			ExpectedObject: exportCounts{nodes: 3},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Unknown format",
+			Url:            "/export?format=csv",
+			RouteUrl:       "/export",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.Export),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			log.Debug("received : " + string(body))
+			assert := assert.New(t)
+			switch expected := tc.ExpectedObject.(type) {
+			case int:
+				// the export must be readable by the import
+				count := 0
+				dec := cquads.NewDecoder(bytes.NewReader(body))
+				for _, err := dec.Unmarshal(); err == nil; _, err = dec.Unmarshal() {
+					count++
+				}
+				assert.Equal(expected, count, tc.Description+" -quads")
+			case exportCounts:
+				var graph struct {
+					Nodes     []model.Node     `json:"nodes"`
+					Relations []model.Relation `json:"relations"`
+					Metadata  []model.Metadata `json:"metadata"`
+				}
+				err := json.Unmarshal(body, &graph)
+				assert.NoError(err, tc.Description)
+				assert.Len(graph.Nodes, expected.nodes, tc.Description+" -nodes")
+				assert.Len(graph.Relations, expected.relations, tc.Description+" -relations")
+				assert.Len(graph.Metadata, expected.metadata, tc.Description+" -metadata")
+			default:
+				if tc.ExpectedCode == http.StatusOK {
+					var document []map[string]interface{}
+					assert.NoError(json.Unmarshal(body, &document), tc.Description)
+					assert.NotEmpty(document, tc.Description)
+				}
+			}
+		})
+}
@@ -0,0 +1,43 @@
+package aceweb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/model"
+)
+
+// DefaultReadyTimeout is the time allowed for the store to answer a readiness check
+const DefaultReadyTimeout = 2 * time.Second
+
+// Healthz will report that the process is able to serve requests.  The store is not checked
+// router.HandleFunc("/healthz", Healthz).Methods("GET")
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	ReturnBodyJSON(w, model.Health{Status: "ok", Backend: s.backend, Version: version}, http.StatusOK)
+}
+
+// Readyz will query the store and report the backend, the time taken and the version.  503 is returned when the store
+// cannot be queried within the ready timeout
+// router.HandleFunc("/readyz", Readyz).Methods("GET")
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.readyTimeout)
+	defer cancel()
+
+	latency, err := s.health.Ping(ctx)
+	health := model.Health{
+		Status:  "ok",
+		Backend: s.backend,
+		Version: version,
+		Latency: float64(latency) / float64(time.Millisecond),
+	}
+	if err != nil {
+		log.WithContext(r.Context()).Warn("Readiness check failed - ", err)
+		health.Status = "unavailable"
+		health.Error = err.Error()
+		ReturnBodyJSON(w, health, http.StatusServiceUnavailable)
+		return
+	}
+	ReturnBodyJSON(w, health, http.StatusOK)
+}
@@ -0,0 +1,33 @@
+package aceweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthControllers(t *testing.T) {
+	srv := NewServer(internal.MakeTestStore(t))
+	srv.SetHealthCheck("memstore", 0)
+
+	checkHealth := func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+		var health model.Health
+		assert.NoError(t, json.Unmarshal(body, &health), tc.Description)
+		assert.Equal(t, "ok", health.Status, tc.Description)
+		assert.Equal(t, "memstore", health.Backend, tc.Description)
+		assert.Equal(t, version, health.Version, tc.Description)
+		assert.Empty(t, health.Error, tc.Description)
+	}
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Process alive", Url: "/healthz", ExpectedCode: http.StatusOK},
+	}, "GET", srv.Healthz, checkHealth)
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{Description: "Store reachable", Url: "/readyz", ExpectedCode: http.StatusOK},
+	}, "GET", srv.Readyz, checkHealth)
+	assert.Equal(t, DefaultReadyTimeout, srv.readyTimeout, "ready timeout defaults when not set")
+}
@@ -0,0 +1,46 @@
+package aceweb
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	log "github.com/gkontos/gasket/acelog"
+)
+
+// NQuadsMediaType is the content type of an N-Quads document
+const NQuadsMediaType = "application/n-quads"
+
+// Import will load the N-Quads request body into the store and return a summary of the quads loaded.
+// The body is streamed, the optional batchSize query parameter sets the number of quads in each transaction
+// router.HandleFunc("/import", Import).Methods("POST")
+func (s *Server) Import(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != NQuadsMediaType {
+		ReturnErrorJSON(w, &MediaTypeError{
+			Message: "Unsupported content type",
+			Err:     fmt.Errorf("expected %s", NQuadsMediaType),
+		})
+		return
+	}
+
+	batchSize := 0
+	if value := r.URL.Query().Get("batchSize"); value != "" {
+		if batchSize, err = strconv.Atoi(value); err != nil || batchSize < 1 {
+			ReturnErrorJSON(w, &RequestParseError{Message: "Invalid batchSize", Err: fmt.Errorf("batchSize must be a positive integer")})
+			return
+		}
+	}
+
+	// batches are logged by the import service
+	summary, err := s.importer.ImportNQuads(r.Context(), r.Body, batchSize, nil)
+	if err != nil {
+		log.WithContext(r.Context()).WithField("imported", summary.Imported).Error("Import failed : ", err)
+		ReturnErrorJSON(w, err)
+		return
+	}
+	ReturnBodyJSON(w, summary, http.StatusOK)
+}
@@ -0,0 +1,66 @@
+package aceweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	log "github.com/gkontos/gasket/acelog"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportController(t *testing.T) {
+	nquads := []byte(`# new painting
+<456789012> <schema:name> "Convergence" "test" .
+<456789012> <creator> "Jackson Pollock" "test" .
+<123456789> <color> "yellow" "test" .
+<456789012> this is not a quad
+`)
+	tests := []internal.ControllerTestCase{
+		{
+			Description:    "Import",
+			Url:            "/import?batchSize=1",
+			RouteUrl:       "/import",
+			Body:           nquads,
+			ContentType:    "application/n-quads",
+			ExpectedObject: &model.ImportSummary{Imported: 2, Skipped: 1, Invalid: 1, Batches: 2},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Import again",
+			Url:            "/import",
+			RouteUrl:       "/import",
+			Body:           nquads,
+			ContentType:    "application/n-quads; charset=utf-8",
+			ExpectedObject: &model.ImportSummary{Imported: 0, Skipped: 3, Invalid: 1, Batches: 0},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Wrong content type",
+			Url:            "/import",
+			RouteUrl:       "/import",
+			Body:           []byte(`{"name" : "Convergence"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusUnsupportedMediaType,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(srv.Import),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			log.Debug("received : " + string(body))
+			assert := assert.New(t)
+			if tc.ExpectedObject != nil {
+				var summary model.ImportSummary
+				err := json.Unmarshal(body, &summary)
+				assert.NoError(err)
+				expected := tc.ExpectedObject.(*model.ImportSummary)
+				assert.Equal(expected.Imported, summary.Imported, tc.Description+" -imported")
+				assert.Equal(expected.Skipped, summary.Skipped, tc.Description+" -skipped")
+				assert.Equal(expected.Invalid, summary.Invalid, tc.Description+" -invalid")
+				assert.Equal(expected.Batches, summary.Batches, tc.Description+" -batches")
+				assert.Len(summary.Errors, expected.Invalid, tc.Description+" -errors")
+			}
+		})
+}
@@ -10,7 +10,6 @@ import (
 	"os"
 	"testing"
 
-	"github.com/gkontos/gasket/aceservice"
 	"github.com/cayleygraph/cayley"
 	_ "github.com/cayleygraph/cayley/graph/memstore"
 	"github.com/cayleygraph/cayley/quad"
@@ -31,7 +30,6 @@ func MakeTestStore(t testing.TB) *cayley.Handle {
 	for _, q := range simpleGraph {
 		h.AddQuad(q)
 	}
-	aceservice.SetStore(h)
 	return h
 }
 
@@ -40,14 +38,15 @@ type ControllerTestCase struct {
 	Url            string
 	RouteUrl       string // must be set for gorilla/mux to pick up url parameters (otherwise not needed)
 	Body           []byte
+	ContentType    string // defaults to application/json
 	ExpectedObject interface{}
 	ExpectedCode   int
 }
 
 type ResponseTest func(t *testing.T, body []byte, testCase ControllerTestCase)
 
+// RunControllerTests will run each test case against the handler.  The handler should be bound to a store created with MakeTestStore
 func RunControllerTests(t *testing.T, tests []ControllerTestCase, httpMethod string, handler http.HandlerFunc, responseTest ResponseTest) {
-	MakeTestStore(t)
 	assert := assert.New(t)
 
 	for _, tc := range tests {
@@ -57,7 +56,10 @@ func RunControllerTests(t *testing.T, tests []ControllerTestCase, httpMethod str
 
 		fmt.Println(u.String())
 		req, err := http.NewRequest(httpMethod, u.String(), bytes.NewBuffer(tc.Body))
-		req.Header.Set("Content-Type", "application/json")
+		if tc.ContentType == "" {
+			tc.ContentType = "application/json"
+		}
+		req.Header.Set("Content-Type", tc.ContentType)
 
 		assert.NoError(err)
 		resp := httptest.NewRecorder()
@@ -16,18 +16,25 @@ type RelationRequest struct {
 	Relation model.NodeProperty `json:"relation"`
 }
 
-//MetadataAdd will save a metadata struct to the store
-func MetadataAdd(w http.ResponseWriter, r *http.Request) {
+//MetadataAdd will save a metadata struct to the store.  The relation must exist unless the checkReferences query parameter is false
+func (s *Server) MetadataAdd(w http.ResponseWriter, r *http.Request) {
 
 	var metadata model.Metadata
 
-	parseErr := ParseJsonRequest(r, &metadata)
+	checkReferences, err := GetBoolParameter(r, "checkReferences", true)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+
+	parseErr := ParseValidJsonRequest(r, model.MetadataSchema, &metadata)
 
 	if parseErr != nil {
 		ReturnErrorJSON(w, parseErr)
+		return
 	}
 
-	err := service.AddMetadata(&metadata)
+	err = s.metadata.AddMetadata(r.Context(), &metadata, checkReferences)
 	if err != nil {
 		ReturnErrorJSON(w, err)
 		return
@@ -36,13 +43,16 @@ func MetadataAdd(w http.ResponseWriter, r *http.Request) {
 	ReturnBodyJSON(w, metadata, http.StatusCreated)
 }
 
-func MetadataGet(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MetadataGet(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 	metadataID := vars["metadataid"]
 
-	quadList := service.GetMetadataQuadsByID(metadataID)
-
+	quadList, err := s.metadata.GetMetadata(r.Context(), metadataID)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
 	if len(quadList) == 0 {
 		ReturnBlankJSON(w, http.StatusNotFound)
 		return
@@ -50,16 +60,17 @@ func MetadataGet(w http.ResponseWriter, r *http.Request) {
 	metadata, err := service.QuadListToMetadata(quadList)
 	if err != nil {
 		ReturnErrorJSON(w, err)
+		return
 	}
 	ReturnBodyJSON(w, metadata, http.StatusOK)
 
 }
 
-func MetadataDelete(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MetadataDelete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	metadataID := vars["metadataid"]
 
-	err := service.DeleteMetadataQuads(metadataID)
+	err := s.metadata.DeleteMetadataQuads(r.Context(), metadataID)
 
 	if err != nil {
 		ReturnErrorJSON(w, err)
@@ -69,13 +80,13 @@ func MetadataDelete(w http.ResponseWriter, r *http.Request) {
 }
 
 //MetadataUpdate will add or update quads associated with the metadataid.  It will return the resulting metadata struct
-func MetadataUpdate(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MetadataUpdate(w http.ResponseWriter, r *http.Request) {
 	var metadata model.Metadata
 
 	vars := mux.Vars(r)
 	metadataID := vars["metadataid"]
 
-	parseErr := ParseJsonRequest(r, &metadata)
+	parseErr := ParseValidJsonRequest(r, model.MetadataUpdateSchema, &metadata)
 	if parseErr != nil {
 		ReturnErrorJSON(w, parseErr)
 		return
@@ -91,9 +102,16 @@ func MetadataUpdate(w http.ResponseWriter, r *http.Request) {
 		metadata.ID = quad.IRI(metadataID)
 	}
 
-	err := service.UpdateMetadata(metadata)
+	if err := s.metadata.UpdateMetadata(r.Context(), metadata); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
 
-	quadList := service.GetMetadataQuadsByID(string(metadata.ID))
+	quadList, err := s.metadata.GetMetadata(r.Context(), string(metadata.ID))
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
 
 	metadata, err = service.QuadListToMetadata(quadList)
 
@@ -22,11 +22,19 @@ func TestMetadataCreateController(t *testing.T) {
 	log.Debug(timeValue)
 	tests := []internal.ControllerTestCase{
 		{
-			Description:    "Junk Input",
+			Description: "Junk Input",
+			Url:         "/metadata",
+			Body:        []byte(`{"title":"Buy cheese and bread for breakfast.", "relationId":["klmnopqrst001"]}`),
+			ExpectedObject: []model.Violation{
+				{Pointer: "/relationId", Message: "must be a string"},
+			},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Description:    "Relation does not exist",
 			Url:            "/metadata",
-			Body:           []byte(`{"title":"Buy cheese and bread for breakfast."}`),
-			ExpectedObject: &model.Metadata{}, // a blank expected object will not run tests for the property values
-			ExpectedCode:   http.StatusCreated,
+			Body:           []byte(`{"relationId" : "IWillNotBeFound", "source" : "coffee shop"}`),
+			ExpectedObject: []string{"IWillNotBeFound"},
+			ExpectedCode:   http.StatusUnprocessableEntity,
 		}, {
 			Description: "OK",
 			Url:         "/metadata",
@@ -47,11 +55,22 @@ func TestMetadataCreateController(t *testing.T) {
 		},
 	}
 
-	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(MetadataAdd),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(srv.MetadataAdd),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			log.Debug("received : ", string(body))
 			assert := assert.New(t)
+			if violations, ok := tc.ExpectedObject.([]model.Violation); ok {
+				assertViolations(t, violations, body, tc.Description)
+				return
+			}
+			if missing, ok := tc.ExpectedObject.([]string); ok {
+				var response jsonErr
+				assert.NoError(json.Unmarshal(body, &response), tc.Description)
+				assert.Equal(missing, response.Missing, tc.Description+" -missing")
+				return
+			}
 			var metadata model.Metadata
 			err := json.Unmarshal(body, &metadata)
 			assert.NoError(err)
@@ -85,7 +104,8 @@ func TestMetadataDelete(t *testing.T) {
 		//		},
 	}
 
-	internal.RunControllerTests(t, tests, "DELETE", http.HandlerFunc(MetadataDelete),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "DELETE", http.HandlerFunc(srv.MetadataDelete),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 
 		})
@@ -119,7 +139,8 @@ func TestMetadataGetController(t *testing.T) {
 		},
 	}
 
-	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(MetadataGet),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.MetadataGet),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			log.Debug("received : " + string(body))
@@ -137,6 +158,31 @@ func TestMetadataGetController(t *testing.T) {
 		})
 }
 
+func TestMetadataGetInvalid(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description:  "Relation id is not an IRI",
+			RouteUrl:     "/metadata/{metadataid}",
+			Url:          "/metadata/brokenmeta01",
+			Body:         []byte(``),
+			ExpectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	store := internal.MakeTestStore(t)
+	store.AddQuad(quad.Make(quad.String("abcdefghij001"), model.MetaidPredicate, quad.IRI("brokenmeta01"), nil))
+	store.AddQuad(quad.Make(quad.IRI("brokenmeta01"), quad.IRI("source"), quad.String("interweb.com"), nil))
+	srv := NewServer(store)
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.MetadataGet),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			// only the error is written
+			var message string
+			if assert.NoError(t, json.Unmarshal(body, &message), tc.Description) {
+				assert.Contains(t, message, "is not an IRI", tc.Description)
+			}
+		})
+}
+
 func TestMetadataPutController(t *testing.T) {
 	idExists := "zyx987654321"
 	//	idDoesNotExist := "IWillNotBeFound"
@@ -190,7 +236,8 @@ func TestMetadataPutController(t *testing.T) {
 		//		},
 	}
 
-	internal.RunControllerTests(t, tests, "PUT", http.HandlerFunc(MetadataUpdate),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "PUT", http.HandlerFunc(srv.MetadataUpdate),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			log.Debug("received : " + string(body))
@@ -13,14 +13,14 @@ import (
 )
 
 // NodeCreate expects to receive a json node struct.  The node will be added to the store
-func NodeCreate(w http.ResponseWriter, r *http.Request) {
+func (s *Server) NodeCreate(w http.ResponseWriter, r *http.Request) {
 
 	var node model.Node
 
-	parseErr := ParseJsonRequest(r, &node)
+	parseErr := ParseValidJsonRequest(r, model.NodeSchema, &node)
 
 	if parseErr != nil {
-		log.Error(parseErr)
+		log.WithContext(r.Context()).Error(parseErr)
 		ReturnErrorJSON(w, parseErr)
 		return
 	}
@@ -29,7 +29,7 @@ func NodeCreate(w http.ResponseWriter, r *http.Request) {
 	// foreach other property create a quad with node id as subject and the NodeProperties as the remaining quad values
 	// call AddQuad to save the properties
 
-	quadList, err := service.AddNode(node)
+	quadList, err := s.nodes.AddNode(r.Context(), node)
 
 	if err != nil {
 		ReturnErrorJSON(w, err)
@@ -45,18 +45,61 @@ func NodeCreate(w http.ResponseWriter, r *http.Request) {
 
 }
 
-// NodeDelete will delete all quads for the object node specified by the {id}
+// NodeList will return a page of nodes.  The limit and cursor query parameters control paging,
+// where[key] parameters restrict the nodes to those with matching properties
+// router.HandleFunc("/nodes", NodeList).Methods("GET")
+func (s *Server) NodeList(w http.ResponseWriter, r *http.Request) {
+
+	limit, after, parseErr := GetPageParameters(r)
+	if parseErr != nil {
+		ReturnErrorJSON(w, parseErr)
+		return
+	}
+	filters, parseErr := GetPropertyFilters(r)
+	if parseErr != nil {
+		ReturnErrorJSON(w, parseErr)
+		return
+	}
+
+	nodes, more, err := s.nodes.ListNodes(r.Context(), filters, after, limit)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+
+	page := model.NodePage{Nodes: nodes}
+	if page.Nodes == nil {
+		page.Nodes = []model.Node{}
+	}
+	if more {
+		page.Cursor = EncodeCursor(model.UnEscapeIRI(nodes[len(nodes)-1].ID))
+	}
+	ReturnBodyJSON(w, page, http.StatusOK)
+}
+
+// NodeDelete will delete all quads for the object node specified by the {id}.  A node with relations is only deleted
+// when the cascade query parameter is true, the relations and their metadata are then deleted with the node
 // router.HandleFunc("/nodes/{id}", NodeDelete).Methods("DELETE")
-func NodeDelete(w http.ResponseWriter, r *http.Request) {
+func (s *Server) NodeDelete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	var nodeID string
 	nodeID = vars["id"]
 
-	deleteErr := service.DeleteByID(nodeID)
+	cascade, err := GetBoolParameter(r, "cascade", false)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+
+	found, deleteErr := s.nodes.DeleteByID(r.Context(), nodeID, cascade)
 	if deleteErr != nil {
 		ReturnErrorJSON(w, deleteErr)
 		return
 	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
 
 	ReturnBlankJSON(w, http.StatusNoContent)
 
@@ -64,36 +107,37 @@ func NodeDelete(w http.ResponseWriter, r *http.Request) {
 
 // NodeGet will get the quads relating to the node specified by the {id}
 // router.HandleFunc("/nodes/{id}", NodeGet).Methods("GET")
-func NodeGet(w http.ResponseWriter, r *http.Request) {
+func (s *Server) NodeGet(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 	var subject string
 	subject = vars["id"]
 
-	quadList := service.GetQuadsBySubject(subject)
-
-	if len(quadList) == 0 {
-		ReturnBlankJSON(w, http.StatusNotFound)
-		return
-	}
-	node, err := service.QuadListToNode(quadList)
+	node, found, err := s.nodes.GetNode(r.Context(), subject)
 	if err != nil {
 		ReturnErrorJSON(w, err)
-	} else {
-		ReturnBodyJSON(w, node, http.StatusOK)
+		return
 	}
-	return
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	ReturnBodyJSON(w, node, http.StatusOK)
 }
 
 // NodeGetRelationships will get the quads relating to the node specified by the {id}
 // router.HandleFunc("/nodes/{id}/relationships", NodeGet).Methods("GET")
-func NodeGetRelationships(w http.ResponseWriter, r *http.Request) {
+func (s *Server) NodeGetRelationships(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 	var subject string
 	subject = vars["id"]
 
-	quadList := service.GetQuads(subject)
+	quadList, err := s.nodes.NodeQuads(r.Context(), subject)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
 	if len(quadList) == 0 {
 		ReturnBlankJSON(w, http.StatusNoContent)
 		return
@@ -102,16 +146,41 @@ func NodeGetRelationships(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// NodeNeighborhood will return the nodes and relations within depth hops of the node specified by the {id}
+// router.HandleFunc("/nodes/{id}/neighborhood", NodeNeighborhood).Methods("GET")
+func (s *Server) NodeNeighborhood(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	nodeID := vars["id"]
+
+	depth, direction, types, parseErr := GetTraversalParameters(r)
+	if parseErr != nil {
+		ReturnErrorJSON(w, parseErr)
+		return
+	}
+
+	subgraph, found, err := s.traversal.Neighborhood(r.Context(), nodeID, depth, direction, types)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	ReturnBodyJSON(w, subgraph, http.StatusOK)
+}
+
 // NodeUpdate will update or add quad properties for the {id}
 // router.HandleFunc("/nodes/{id}", NodeUpdateProperty).Methods("PUT")
-func NodeUpdate(w http.ResponseWriter, r *http.Request) {
+func (s *Server) NodeUpdate(w http.ResponseWriter, r *http.Request) {
 
 	var node model.Node
 
 	vars := mux.Vars(r)
 	nodeID := vars["id"]
 
-	parseErr := ParseJsonRequest(r, &node)
+	parseErr := ParseValidJsonRequest(r, model.NodeUpdateSchema, &node)
 
 	if parseErr != nil {
 		ReturnErrorJSON(w, parseErr)
@@ -130,14 +199,13 @@ func NodeUpdate(w http.ResponseWriter, r *http.Request) {
 
 		node.ID = quad.IRI(nodeID)
 	}
-	_, err := service.UpdateNode(node)
+	_, err := s.nodes.UpdateNode(r.Context(), node)
 	if err != nil {
 		ReturnErrorJSON(w, err)
 		return
 	}
-	quadList := service.GetQuadsBySubject(nodeID)
 
-	node, mappingErr := service.QuadListToNode(quadList)
+	node, _, mappingErr := s.nodes.GetNode(r.Context(), nodeID)
 	if mappingErr != nil {
 		ReturnErrorJSON(w, mappingErr)
 		return
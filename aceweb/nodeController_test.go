@@ -1,6 +1,7 @@
 package aceweb
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -17,11 +18,16 @@ func TestNodeCreateController(t *testing.T) {
 
 	tests := []internal.ControllerTestCase{
 		{
-			Description:    "Junk Input",
-			Url:            "/nodes",
-			Body:           []byte(`{"title":"Buy cheese and bread for breakfast."}`),
-			ExpectedObject: &model.Node{}, // a blank expected object will not run tests for the property values
-			ExpectedCode:   http.StatusCreated,
+			Description: "Junk Input",
+			Url:         "/nodes",
+			Body:        []byte(`{"title":"Buy cheese and bread for breakfast.", "a/b":"c", "size":{"width":1}, "label":7}`),
+			ExpectedObject: []model.Violation{
+				{Pointer: "/name", Message: "is required"},
+				{Pointer: "/a~1b", Message: "property name must match ^[^/]+$"},
+				{Pointer: "/label", Message: "must be a string"},
+				{Pointer: "/size", Message: "must be a string, number or boolean"},
+			},
+			ExpectedCode: http.StatusBadRequest,
 		}, {
 			Description: "OK",
 			Url:         "/nodes",
@@ -42,10 +48,15 @@ func TestNodeCreateController(t *testing.T) {
 		},
 	}
 
-	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(NodeCreate),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(srv.NodeCreate),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			assert := assert.New(t)
+			if violations, ok := tc.ExpectedObject.([]model.Violation); ok {
+				assertViolations(t, violations, body, tc.Description)
+				return
+			}
 			var node model.Node
 			err := json.Unmarshal(body, &node)
 			assert.NoError(err)
@@ -61,27 +72,54 @@ func TestNodeCreateController(t *testing.T) {
 
 func TestNodeDeleteController(t *testing.T) {
 	idExists := "123456789"
-	//	idDoesNotExist := "IWillNotBeFound"
+	idDoesNotExist := "IWillNotBeFound"
 	tests := []internal.ControllerTestCase{
 		{
-			Description:    "Node exists",
+			Description:    "Node has relations",
+			RouteUrl:       "/nodes/{id}",
 			Url:            "/nodes/" + idExists,
 			Body:           []byte(``),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusConflict,
+		}, {
+			Description:    "Invalid cascade",
+			RouteUrl:       "/nodes/{id}",
+			Url:            "/nodes/" + idExists + "?cascade=sometimes",
+			Body:           []byte(``),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		}, {
+			Description:    "Cascade",
+			RouteUrl:       "/nodes/{id}",
+			Url:            "/nodes/" + idExists + "?cascade=true",
+			Body:           []byte(``),
 			ExpectedObject: &model.Node{}, // a blank expected object will not run tests for the property values
 			ExpectedCode:   http.StatusNoContent,
+		}, {
+			Description:    "Does not exist",
+			RouteUrl:       "/nodes/{id}",
+			Url:            "/nodes/" + idDoesNotExist,
+			Body:           []byte(``),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
 		},
-		//		{
-		//			Description:    "Does not exist",
-		//			Url:            "/nodes/" + idDoesNotExist,
-		//			Body:           []byte(``),
-		//			ExpectedObject: &model.Node{},
-		//			ExpectedCode:   http.StatusNotFound,
-		//		},
 	}
 
-	internal.RunControllerTests(t, tests, "DELETE", http.HandlerFunc(NodeDelete),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "DELETE", http.HandlerFunc(srv.NodeDelete),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
-
+			assert := assert.New(t)
+			if tc.ExpectedCode == http.StatusNoContent {
+				// the relation from the node, its relation id and its metadata are removed
+				assert.Empty(srv.nodes.GetQuadsBySubject(idExists), tc.Description+" -node")
+				relation, err := srv.relations.GetRelation(context.Background(), "abcdefghij001")
+				assert.NoError(err, tc.Description)
+				assert.Equal(quad.IRI(""), relation.ID, tc.Description+" -relation")
+				assert.Empty(srv.metadata.GetMetadataQuadsByID("zyx987654321"), tc.Description+" -metadata")
+				assert.Len(srv.relations.RelationIDIndex(), 1, tc.Description+" -relation ids")
+			} else if tc.ExpectedCode != http.StatusNotFound {
+				assert.NotEmpty(srv.nodes.GetQuadsBySubject(idExists), tc.Description+" -node")
+			}
 		})
 }
 
@@ -113,7 +151,8 @@ func TestNodeGetController(t *testing.T) {
 		},
 	}
 
-	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(NodeGet),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.NodeGet),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			log.Debug("received : " + string(body))
@@ -180,7 +219,8 @@ func TestNodePutController(t *testing.T) {
 		//		},
 	}
 
-	internal.RunControllerTests(t, tests, "PUT", http.HandlerFunc(NodeUpdate),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "PUT", http.HandlerFunc(srv.NodeUpdate),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			log.Debug("received : " + string(body))
@@ -198,3 +238,225 @@ func TestNodePutController(t *testing.T) {
 			}
 		})
 }
+
+func TestNodeListController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description: "First page",
+			RouteUrl:    "/nodes",
+			Url:         "/nodes?limit=2",
+			ExpectedObject: &model.NodePage{
+				Nodes:  []model.Node{{ID: "123456789"}, {ID: "234567890"}},
+				Cursor: EncodeCursor("234567890"),
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description: "Last page",
+			RouteUrl:    "/nodes",
+			Url:         "/nodes?limit=2&cursor=" + EncodeCursor("234567890"),
+			ExpectedObject: &model.NodePage{
+				Nodes: []model.Node{{ID: "345678901", Name: "Jacqueline"}},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description:    "Invalid limit",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?limit=none",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.NodeList),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			log.Debug("received : " + string(body))
+			assert := assert.New(t)
+			if tc.ExpectedObject != nil {
+				var page model.NodePage
+				err := json.Unmarshal(body, &page)
+				assert.NoError(err)
+				expectedPage := tc.ExpectedObject.(*model.NodePage)
+				assert.Equal(expectedPage.Cursor, page.Cursor, tc.Description+" -cursor")
+				if assert.Len(page.Nodes, len(expectedPage.Nodes), tc.Description) {
+					for i, expectedNode := range expectedPage.Nodes {
+						assert.Equal(expectedNode.ID, page.Nodes[i].ID, tc.Description+" -id")
+						if expectedNode.Name != "" {
+							assert.Equal(expectedNode.Name, page.Nodes[i].Name, tc.Description+" -name")
+						}
+					}
+				}
+			}
+		})
+}
+
+func TestNodeSearchController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description:    "Equal",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[color]=yellow",
+			ExpectedObject: []string{"Shimmering Substance"},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Multiple matches",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[style]=abstract%20expressionist",
+			ExpectedObject: []string{"Shimmering Substance", "One: Number"},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Intersection",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[rdf:type]=painting&where[creator]=Pablo%20Picasso",
+			ExpectedObject: []string{"Jacqueline"},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Prefix",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[name][prefix]=One",
+			ExpectedObject: []string{"One: Number"},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Range",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[amount][gt]=10&where[amount][lte]=11.11",
+			ExpectedObject: []string{"node search test"},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "No match",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[amount][lt]=10",
+			ExpectedObject: []string{},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Non numeric range",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[amount][gt]=ten",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		}, {
+			Description:    "Unknown operator",
+			RouteUrl:       "/nodes",
+			Url:            "/nodes?where[color][like]=yellow",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	_, err := srv.nodes.AddNode(context.Background(), model.Node{
+		Name:       "node search test",
+		Properties: model.NewProperties(model.PropertyValue{Key: "amount", Value: quad.Float(11.11)}),
+	})
+	assert.NoError(t, err)
+
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.NodeList),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			log.Debug("received : " + string(body))
+			assert := assert.New(t)
+			if tc.ExpectedObject != nil {
+				var page model.NodePage
+				err := json.Unmarshal(body, &page)
+				assert.NoError(err)
+				expectedNames := tc.ExpectedObject.([]string)
+				if assert.Len(page.Nodes, len(expectedNames), tc.Description) {
+					for _, node := range page.Nodes {
+						assert.Contains(expectedNames, node.Name, tc.Description)
+					}
+				}
+			}
+		})
+}
+
+func TestNodeNeighborhoodController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description: "Incoming relations",
+			RouteUrl:    "/nodes/{id}/neighborhood",
+			Url:         "/nodes/234567890/neighborhood",
+			ExpectedObject: &model.Graph{
+				Nodes: []model.Node{{ID: "234567890"}, {ID: "123456789"}, {ID: "345678901"}},
+				Relations: []model.Relation{
+					{ID: "abcdefghij001", SourceID: "123456789", Type: "similarto", TargetID: "234567890"},
+					{ID: "klmnopqrst001", SourceID: "345678901", Type: "influenced", TargetID: "234567890"},
+				},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description:    "No outgoing relations",
+			RouteUrl:       "/nodes/{id}/neighborhood",
+			Url:            "/nodes/234567890/neighborhood?direction=out",
+			ExpectedObject: &model.Graph{Nodes: []model.Node{{ID: "234567890"}}},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description: "Two hops",
+			RouteUrl:    "/nodes/{id}/neighborhood",
+			Url:         "/nodes/123456789/neighborhood?depth=2",
+			ExpectedObject: &model.Graph{
+				Nodes: []model.Node{{ID: "123456789"}, {ID: "234567890"}, {ID: "345678901"}},
+				Relations: []model.Relation{
+					{ID: "abcdefghij001", SourceID: "123456789", Type: "similarto", TargetID: "234567890"},
+					{ID: "klmnopqrst001", SourceID: "345678901", Type: "influenced", TargetID: "234567890"},
+				},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description:    "Relation type",
+			RouteUrl:       "/nodes/{id}/neighborhood",
+			Url:            "/nodes/123456789/neighborhood?depth=2&type=influenced",
+			ExpectedObject: &model.Graph{Nodes: []model.Node{{ID: "123456789"}}},
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Invalid direction",
+			RouteUrl:       "/nodes/{id}/neighborhood",
+			Url:            "/nodes/123456789/neighborhood?direction=sideways",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		}, {
+			Description:    "Does not exist",
+			RouteUrl:       "/nodes/{id}/neighborhood",
+			Url:            "/nodes/IWillNotBeFound/neighborhood",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.NodeNeighborhood),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			log.Debug("received : " + string(body))
+			assert := assert.New(t)
+			if tc.ExpectedObject != nil {
+				var subgraph model.Graph
+				err := json.Unmarshal(body, &subgraph)
+				assert.NoError(err)
+				expectedGraph := tc.ExpectedObject.(*model.Graph)
+				if assert.Len(subgraph.Nodes, len(expectedGraph.Nodes), tc.Description+" -nodes") {
+					for i, expectedNode := range expectedGraph.Nodes {
+						assert.Equal(expectedNode.ID, subgraph.Nodes[i].ID, tc.Description+" -node")
+					}
+				}
+				if assert.Len(subgraph.Relations, len(expectedGraph.Relations), tc.Description+" -relations") {
+					for _, expectedRelation := range expectedGraph.Relations {
+						assert.Contains(subgraph.Relations, expectedRelation, tc.Description+" -relation")
+					}
+				}
+			}
+		})
+}
+
+// assertViolations will check that the error response lists the expected violations
+func assertViolations(t *testing.T, expected []model.Violation, body []byte, description string) {
+	assert := assert.New(t)
+	var response jsonErr
+	err := json.Unmarshal(body, &response)
+	assert.NoError(err, description)
+	assert.Equal(http.StatusBadRequest, response.Code, description+" -code")
+	assert.Len(response.Violations, len(expected), description+" -violations")
+	for _, violation := range expected {
+		assert.Contains(response.Violations, violation, description+" -violation "+violation.Pointer)
+	}
+}
@@ -0,0 +1,60 @@
+package aceweb
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cayleygraph/cayley/quad"
+)
+
+// DefaultPathDepth is the maximum length of a path when maxDepth is not requested
+const DefaultPathDepth = 6
+
+// PathGet will return the relations along the shortest path between the from and to nodes
+// router.HandleFunc("/paths", PathGet).Methods("GET")
+func (s *Server) PathGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from := query.Get("from")
+	to := query.Get("to")
+	if from == "" || to == "" {
+		ReturnErrorJSON(w, &RequestParseError{Message: "Invalid path request", Err: fmt.Errorf("from and to are required")})
+		return
+	}
+
+	maxDepth := DefaultPathDepth
+	if value := query.Get("maxDepth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			ReturnErrorJSON(w, &RequestParseError{Message: "Unable to parse maxDepth", Err: err})
+			return
+		}
+		if parsed < 1 || parsed > MaxTraversalDepth {
+			ReturnErrorJSON(w, &RequestParseError{Message: "Invalid maxDepth", Err: fmt.Errorf("maxDepth must be between 1 and %d", MaxTraversalDepth)})
+			return
+		}
+		maxDepth = parsed
+	}
+
+	var types []quad.IRI
+	for _, value := range query["types"] {
+		for _, relationType := range strings.Split(value, ",") {
+			if relationType != "" {
+				types = append(types, quad.IRI(relationType))
+			}
+		}
+	}
+
+	path, found, err := s.traversal.ShortestPath(r.Context(), from, to, maxDepth, types)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	ReturnBodyJSON(w, path, http.StatusOK)
+}
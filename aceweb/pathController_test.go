@@ -0,0 +1,78 @@
+package aceweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cayleygraph/cayley/quad"
+	log "github.com/gkontos/gasket/acelog"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathGetController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description: "Single hop",
+			Url:         "/paths?from=123456789&to=234567890",
+			RouteUrl:    "/paths",
+			ExpectedObject: []model.Relation{
+				{ID: "abcdefghij001", SourceID: "123456789", Type: "similarto", TargetID: "234567890"},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description: "Two hops",
+			Url:         "/paths?from=345678901&to=456789012",
+			RouteUrl:    "/paths",
+			ExpectedObject: []model.Relation{
+				{ID: "klmnopqrst001", SourceID: "345678901", Type: "influenced", TargetID: "234567890"},
+				{SourceID: "234567890", Type: "inspired", TargetID: "456789012"},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description:    "Relation types",
+			Url:            "/paths?from=345678901&to=456789012&types=influenced,similarto",
+			RouteUrl:       "/paths",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
+		}, {
+			Description:    "Too deep",
+			Url:            "/paths?from=345678901&to=456789012&maxDepth=1",
+			RouteUrl:       "/paths",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
+		}, {
+			Description:    "Against relation direction",
+			Url:            "/paths?from=234567890&to=123456789",
+			RouteUrl:       "/paths",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
+		}, {
+			Description:    "Missing to",
+			Url:            "/paths?from=234567890",
+			RouteUrl:       "/paths",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		},
+	}
+
+	store := internal.MakeTestStore(t)
+	store.AddQuad(quad.Make(quad.IRI("456789012"), model.NamePredicate, quad.String("Convergence"), quad.String("test")))
+	store.AddQuad(quad.Make(quad.IRI("234567890"), quad.IRI("inspired"), quad.IRI("456789012"), nil))
+
+	srv := NewServer(store)
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.PathGet),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			log.Debug("received : " + string(body))
+			assert := assert.New(t)
+			if tc.ExpectedObject != nil {
+				var path []model.Relation
+				err := json.Unmarshal(body, &path)
+				assert.NoError(err)
+				assert.Equal(tc.ExpectedObject, path, tc.Description)
+			}
+		})
+}
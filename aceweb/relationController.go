@@ -1,22 +1,28 @@
 package aceweb
 
 import (
+	"fmt"
 	"net/http"
 
-	service "github.com/gkontos/gasket/aceservice"
+	"github.com/cayleygraph/cayley/quad"
 	"github.com/gkontos/gasket/model"
 	"github.com/gorilla/mux"
 )
 
 // RelationCreate add a relation
-// return the created relation object
-func RelationCreate(w http.ResponseWriter, r *http.Request) {
+// return the created relation object.  The source and target nodes must exist unless the checkReferences query parameter is false
+func (s *Server) RelationCreate(w http.ResponseWriter, r *http.Request) {
 	var relation model.Relation
-	if err := ParseJsonRequest(r, &relation); err != nil {
+	checkReferences, err := GetBoolParameter(r, "checkReferences", true)
+	if err != nil {
 		ReturnErrorJSON(w, err)
 		return
 	}
-	if err := service.AddQuadRelationship(&relation); err != nil {
+	if err := ParseValidJsonRequest(r, model.RelationSchema, &relation); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if err := s.relations.AddQuadRelationship(r.Context(), &relation, checkReferences); err != nil {
 		ReturnErrorJSON(w, err)
 		return
 	}
@@ -24,13 +30,13 @@ func RelationCreate(w http.ResponseWriter, r *http.Request) {
 }
 
 // RelationDelete will delete the relation quad, and its metadata quads
-func RelationDelete(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RelationDelete(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 	var ID string
 	ID = vars["id"]
 
-	if err := service.DeleteByRelationID(ID); err != nil {
+	if err := s.relations.DeleteByRelationID(r.Context(), ID); err != nil {
 		ReturnErrorJSON(w, err)
 		return
 	}
@@ -40,12 +46,16 @@ func RelationDelete(w http.ResponseWriter, r *http.Request) {
 }
 
 // RelationGet will return the relation associated with the given ID
-func RelationGet(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RelationGet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	var ID string
 	ID = vars["id"]
 
-	q := service.GetRelation(ID)
+	q, err := s.relations.GetRelation(r.Context(), ID)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
 	//	if _, ok := q.SourceID.(quad.IRI); ok { // invalid type assertion: q.SourceID.(quad.IRI) (non-interface type quad.IRI on left)
 	// if q.SourceID == nil { // IRI is not type nil
 	// if q.SourceID == (quad.IRI{}) { // invalid type for composite literal
@@ -57,3 +67,44 @@ func RelationGet(w http.ResponseWriter, r *http.Request) {
 	return
 
 }
+
+// RelationUpdate will change the source, type, target or label of the relation with the given ID.  Fields which are not
+// sent keep their current value
+// router.HandleFunc("/relations/{id}", RelationUpdate).Methods("PUT")
+func (s *Server) RelationUpdate(w http.ResponseWriter, r *http.Request) {
+	var relation model.Relation
+
+	vars := mux.Vars(r)
+	relationID := vars["id"]
+
+	if err := ParseValidJsonRequest(r, model.RelationUpdateSchema, &relation); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+
+	if string(relation.ID) != "" && string(relation.ID) != relationID {
+		validErr := &ValidationError{
+			Err:     fmt.Errorf("Unable to process request"),
+			Message: "Received ID's do not match",
+		}
+		ReturnErrorJSON(w, validErr)
+		return
+	}
+	relation.ID = quad.IRI(relationID)
+
+	found, err := s.relations.UpdateRelation(r.Context(), &relation)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	updated, err := s.relations.GetRelation(r.Context(), relationID)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	ReturnBodyJSON(w, updated, http.StatusOK)
+}
@@ -1,6 +1,7 @@
 package aceweb
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"testing"
@@ -18,7 +19,7 @@ func TestRelationCreateController(t *testing.T) {
 		{
 			Description: "Basic Input",
 			Url:         "/relations",
-			Body:        []byte(`{"sourceid":"123456789", "type":"pavedthewayfor","targetid":"234567890"}`),
+			Body:        []byte(`{"sourceId":"123456789", "type":"pavedthewayfor","targetId":"234567890","label":""}`),
 			ExpectedObject: &model.Relation{
 				SourceID: "123456789",
 				Type:     "pavedthewayfor",
@@ -29,16 +30,46 @@ func TestRelationCreateController(t *testing.T) {
 		{
 			Description:    "Id does not exist",
 			Url:            "/relations",
-			Body:           []byte(`{"sourceid":"noid", "type":"pavedthewayfor","targetid":"234567890"}`),
+			Body:           []byte(`{"sourceId":"noid", "type":"pavedthewayfor","targetId":"nothere","label":""}`),
+			ExpectedObject: []string{"noid", "nothere"},
+			ExpectedCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			Description:    "Id does not exist without reference checks",
+			Url:            "/relations?checkReferences=false",
+			RouteUrl:       "/relations",
+			Body:           []byte(`{"sourceId":"noid", "type":"pavedthewayfor","targetId":"234567890","label":""}`),
 			ExpectedObject: nil,
 			ExpectedCode:   http.StatusCreated,
 		},
+		{
+			Description: "Missing fields",
+			Url:         "/relations",
+			Body:        []byte(`{"sourceid":"123456789", "type":"pavedthewayfor"}`),
+			ExpectedObject: []model.Violation{
+				{Pointer: "/sourceId", Message: "is required"},
+				{Pointer: "/targetId", Message: "is required"},
+				{Pointer: "/label", Message: "is required"},
+			},
+			ExpectedCode: http.StatusBadRequest,
+		},
 	}
 
-	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(RelationCreate),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(srv.RelationCreate),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			assert := assert.New(t)
+			if violations, ok := tc.ExpectedObject.([]model.Violation); ok {
+				assertViolations(t, violations, body, tc.Description)
+				return
+			}
+			if missing, ok := tc.ExpectedObject.([]string); ok {
+				var response jsonErr
+				assert.NoError(json.Unmarshal(body, &response), tc.Description)
+				assert.Equal(missing, response.Missing, tc.Description+" -missing")
+				return
+			}
 			var relation model.Relation
 			err := json.Unmarshal(body, &relation)
 			assert.NoError(err)
@@ -72,7 +103,8 @@ func TestRelationDelete(t *testing.T) {
 		//		},
 	}
 
-	internal.RunControllerTests(t, tests, "DELETE", http.HandlerFunc(RelationDelete),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "DELETE", http.HandlerFunc(srv.RelationDelete),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 
 		})
@@ -125,7 +157,8 @@ func TestRelationGetController(t *testing.T) {
 		},
 	}
 
-	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(RelationGet),
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.RelationGet),
 		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
 			log.Debug("running test case : ", tc.Description)
 			assert := assert.New(t)
@@ -143,3 +176,69 @@ func TestRelationGetController(t *testing.T) {
 			}
 		})
 }
+
+func TestRelationUpdateController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description: "Change type and label",
+			RouteUrl:    "/relations/{id}",
+			Url:         "/relations/abcdefghij001",
+			Body:        []byte(`{"type":"inspired", "label":"test"}`),
+			ExpectedObject: &model.Relation{
+				ID:       quad.IRI("abcdefghij001"),
+				SourceID: quad.IRI("123456789"),
+				Type:     quad.IRI("inspired"),
+				TargetID: quad.IRI("234567890"),
+				Label:    quad.String("test"),
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Description:    "Relation already exists",
+			RouteUrl:       "/relations/{id}",
+			Url:            "/relations/klmnopqrst001",
+			Body:           []byte(`{"sourceId":"123456789", "type":"inspired", "label":"test"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusConflict,
+		}, {
+			Description:    "Id does not match",
+			RouteUrl:       "/relations/{id}",
+			Url:            "/relations/klmnopqrst001",
+			Body:           []byte(`{"id":"abcdefghij001", "type":"inspired"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusBadRequest,
+		}, {
+			Description:    "Does not exist",
+			RouteUrl:       "/relations/{id}",
+			Url:            "/relations/IWillNotBeFound",
+			Body:           []byte(`{"type":"inspired"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "PUT", http.HandlerFunc(srv.RelationUpdate),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			assert := assert.New(t)
+			if tc.ExpectedObject != nil {
+				var relation model.Relation
+				err := json.Unmarshal(body, &relation)
+				assert.NoError(err)
+				expectedRelation := tc.ExpectedObject.(*model.Relation)
+
+				assert.Equal(expectedRelation.ID, relation.ID, tc.Description+" -id")
+				assert.Equal(expectedRelation.SourceID, relation.SourceID, tc.Description+" -source")
+				assert.Equal(expectedRelation.Type, relation.Type, tc.Description+" -type")
+				assert.Equal(expectedRelation.TargetID, relation.TargetID, tc.Description+" -target")
+				assert.Equal(expectedRelation.Label, relation.Label, tc.Description+" -label")
+
+				// the stored relation is replaced and the metadata is still attached to the relation id
+				stored, err := srv.relations.GetRelation(context.Background(), string(expectedRelation.ID))
+				assert.NoError(err, tc.Description)
+				assert.Equal(expectedRelation.Type, stored.Type, tc.Description+" -stored type")
+				assert.Len(srv.metadata.MetadataIDsForRelation(string(expectedRelation.ID)), 2, tc.Description+" -metadata")
+				assert.Len(srv.relations.RelationIDIndex(), 2, tc.Description+" -relation ids")
+			}
+		})
+}
@@ -1,6 +1,11 @@
 package aceweb
 
 import (
+	"net/http"
+	"time"
+
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/acemetrics"
 	"github.com/gorilla/mux"
 )
 
@@ -10,35 +15,73 @@ func SetVersion(ver string) {
 	version = ver
 }
 
-// SysViewRouter will return a router for the project routes
-// TODO add auth, cors etc to handlers
+// SysViewRouter will return a router for the project routes bound to the given server.  Requests are authenticated
+// by wrapping the router with AuthHandler, and counted in the metrics by route
+// TODO add cors etc to handlers
 // ie   router.Handle("/v1/x", common.ErrorHandler(stats.GetS)).Methods("GET")
-func SysViewRouter() *mux.Router {
+func SysViewRouter(srv *Server) *mux.Router {
 	router := mux.NewRouter()
 	router.StrictSlash(false)
 	s := router.PathPrefix(version).Subrouter()
+	timeout := srv.writeTimeout
 
-	s.HandleFunc("/nodes", NodeCreate).Methods("POST")
-	s.HandleFunc("/nodes/{id}", NodeDelete).Methods("DELETE")
-	s.HandleFunc("/nodes/{id}", NodeGet).Methods("GET")
-	s.HandleFunc("/nodes/{id}/relationships", NodeGetRelationships).Methods("GET")
-	s.HandleFunc("/nodes/{id}", NodeUpdate).Methods("PUT")
+	handle(s, timeout, "POST", "/nodes", srv.NodeCreate)
+	handle(s, timeout, "GET", "/nodes", srv.NodeList)
+	handle(s, timeout, "DELETE", "/nodes/{id}", srv.NodeDelete)
+	handle(s, timeout, "GET", "/nodes/{id}", srv.NodeGet)
+	handle(s, timeout, "GET", "/nodes/{id}/relationships", srv.NodeGetRelationships)
+	handle(s, timeout, "GET", "/nodes/{id}/neighborhood", srv.NodeNeighborhood)
+	handle(s, timeout, "PUT", "/nodes/{id}", srv.NodeUpdate)
 
 	// Given a quad, return the details of relationship
-	s.HandleFunc("/relations", RelationCreate).Methods("POST")
-	s.HandleFunc("/relations/{id}", RelationGet).Methods("GET")
-	s.HandleFunc("/relations/{id}", RelationDelete).Methods("DELETE")
-	// no PUT available for relationships.  It seems unnecessary to update a quad
+	handle(s, timeout, "POST", "/relations", srv.RelationCreate)
+	handle(s, timeout, "GET", "/relations/{id}", srv.RelationGet)
+	handle(s, timeout, "DELETE", "/relations/{id}", srv.RelationDelete)
+	// the relation id and its metadata are kept when a relation is updated
+	handle(s, timeout, "PUT", "/relations/{id}", srv.RelationUpdate)
+
+	// node types, checked when a node with the rdf:type is saved
+	handle(s, timeout, "POST", "/types", srv.TypeCreate)
+	handle(s, timeout, "GET", "/types", srv.TypeList)
+	handle(s, timeout, "GET", "/types/{name}", srv.TypeGet)
+	handle(s, timeout, "PUT", "/types/{name}", srv.TypeUpdate)
+	handle(s, timeout, "DELETE", "/types/{name}", srv.TypeDelete)
+
+	// bulk load of an N-Quads document
+	handle(s, timeout, "POST", "/import", srv.Import)
+	// the export is streamed, so it is not bounded by the write timeout of the other routes
+	handle(s, 0, "GET", "/export", srv.Export)
+
+	// shortest path between two nodes
+	handle(s, timeout, "GET", "/paths", srv.PathGet)
 
-	s.HandleFunc("/metadata", MetadataAdd).Methods("POST")
+	handle(s, timeout, "POST", "/metadata", srv.MetadataAdd)
 	// alias for /metadata endpoint
-	s.HandleFunc("/relations/{id}/metadata", MetadataAdd).Methods("POST")
+	handle(s, timeout, "POST", "/relations/{id}/metadata", srv.MetadataAdd)
 
-	s.HandleFunc("/metadata/{metadataid}", MetadataGet).Methods("GET")
+	handle(s, timeout, "GET", "/metadata/{metadataid}", srv.MetadataGet)
 	// Delete the metadata for the given quad
-	s.HandleFunc("/metadata/{metadataid}", MetadataDelete).Methods("DELETE")
+	handle(s, timeout, "DELETE", "/metadata/{metadataid}", srv.MetadataDelete)
 	// Add or update the metadata for the given quad
-	s.HandleFunc("/metadata/{metadataid}", MetadataUpdate).Methods("PUT")
+	handle(s, timeout, "PUT", "/metadata/{metadataid}", srv.MetadataUpdate)
+
+	// runtime changes to the log configuration
+	handle(s, timeout, "GET", "/admin/log-level", srv.LogLevelGet)
+	handle(s, timeout, "PUT", "/admin/log-level", srv.LogLevelUpdate)
 
 	return router
 }
+
+// handle will register the handler for the method and path.  Requests are recorded in the metrics under the path template,
+// and the path template is written as the route of entries logged with the request context.  A request which takes longer
+// than timeout is answered with 503 Service Unavailable, and a timeout of 0 leaves the request unbounded.  The response of
+// a bounded request is buffered until the handler returns
+func handle(r *mux.Router, timeout time.Duration, method string, path string, h http.HandlerFunc) {
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h(w, req.WithContext(log.ContextWithFields(req.Context(), log.Fields{"route": method + " " + path})))
+	})
+	if timeout > 0 {
+		handler = http.TimeoutHandler(handler, timeout, "Request timed out")
+	}
+	r.Handle(path, acemetrics.InstrumentHandler(path, handler)).Methods(method)
+}
@@ -0,0 +1,119 @@
+package aceweb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gkontos/gasket/acemetrics"
+	log "github.com/gkontos/gasket/acelog"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterMetrics(t *testing.T) {
+	assert := assert.New(t)
+	SetVersion("/v0")
+	defer SetVersion("v0")
+	router := SysViewRouter(NewServer(internal.MakeTestStore(t)))
+
+	requests := []struct {
+		Method       string
+		Url          string
+		Body         string
+		ExpectedCode int
+	}{
+		{"GET", "/v0/nodes/123456789", "", http.StatusOK},
+		{"GET", "/v0/nodes/IWillNotBeFound", "", http.StatusNotFound},
+		{"POST", "/v0/nodes", `{"name":"metrics test", "label":"test"}`, http.StatusCreated},
+	}
+	for _, tc := range requests {
+		req, err := http.NewRequest(tc.Method, tc.Url, bytes.NewBufferString(tc.Body))
+		assert.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(tc.ExpectedCode, resp.Code, tc.Url)
+	}
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	resp := httptest.NewRecorder()
+	acemetrics.Handler().ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	metrics, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(err)
+
+	for _, expected := range []string{
+		`gasket_http_requests_total{method="GET",route="/nodes/{id}",status="200"}`,
+		`gasket_http_requests_total{method="GET",route="/nodes/{id}",status="404"}`,
+		`gasket_http_request_duration_seconds_count{method="POST",route="/nodes",status="201"}`,
+		`gasket_store_transactions_total{result="ok"}`,
+		`gasket_store_transaction_quads_total{action="add"}`,
+		`gasket_store_scans_total{scan="quads_by_subject"}`,
+	} {
+		assert.Contains(string(metrics), expected)
+	}
+}
+
+func TestRequestLogHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	var requestID string
+	var rw *log.ResponseWriter
+	handler := log.RequestLogHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = log.RequestID(r.Context())
+		rw, _ = w.(*log.ResponseWriter)
+		ReturnBodyJSON(w, "created", http.StatusCreated)
+	}))
+
+	req, _ := http.NewRequest("POST", "/nodes", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	assert.NotEmpty(requestID)
+	assert.Equal(requestID, resp.Header().Get(log.RequestIDHeader))
+	if assert.NotNil(rw) {
+		assert.Equal(http.StatusCreated, rw.Status())
+		assert.Equal(resp.Body.Len(), rw.Size())
+	}
+
+	// each request is given a new id
+	firstID := requestID
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.NotEqual(firstID, resp.Header().Get(log.RequestIDHeader))
+}
+
+func TestRouteWriteTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		ReturnBodyJSON(w, "done", http.StatusOK)
+	}
+	router := mux.NewRouter()
+	handle(router, 10*time.Millisecond, "GET", "/bounded", slow)
+	handle(router, 0, "GET", "/unbounded", slow)
+
+	for path, expectedCode := range map[string]int{"/bounded": http.StatusServiceUnavailable, "/unbounded": http.StatusOK} {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(expectedCode, resp.Code, path)
+	}
+
+	// the export is not bounded by the write timeout of the other routes
+	SetVersion("/v0")
+	defer SetVersion("v0")
+	srv := NewServer(internal.MakeTestStore(t))
+	srv.SetWriteTimeout(time.Nanosecond)
+	req, _ := http.NewRequest("GET", "/v0/export", nil)
+	resp := httptest.NewRecorder()
+	SysViewRouter(srv).ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), "<abcdefghij001>")
+}
@@ -0,0 +1,55 @@
+package aceweb
+
+import (
+	"time"
+
+	"github.com/cayleygraph/cayley"
+	service "github.com/gkontos/gasket/aceservice"
+)
+
+// Server binds the controllers to the services for a single graph store
+type Server struct {
+	nodes     *service.NodeService
+	relations *service.RelationService
+	metadata  *service.MetadataService
+	traversal *service.TraversalService
+	importer  *service.ImportService
+	exporter  *service.ExportService
+	types     *service.TypeService
+	health    *service.HealthService
+
+	backend      string
+	readyTimeout time.Duration
+	writeTimeout time.Duration
+}
+
+// NewServer will return a Server with services for the given store
+func NewServer(store *cayley.Handle) *Server {
+	return &Server{
+		nodes:     service.NewNodeService(store),
+		relations: service.NewRelationService(store),
+		metadata:  service.NewMetadataService(store),
+		traversal: service.NewTraversalService(store),
+		importer:  service.NewImportService(store),
+		exporter:  service.NewExportService(store),
+		types:     service.NewTypeService(store),
+		health:    service.NewHealthService(store),
+
+		readyTimeout: DefaultReadyTimeout,
+	}
+}
+
+// SetHealthCheck will set the backend name reported by the health endpoints, and the time allowed for the store
+// to answer a readiness check
+func (s *Server) SetHealthCheck(backend string, readyTimeout time.Duration) {
+	s.backend = backend
+	if readyTimeout > 0 {
+		s.readyTimeout = readyTimeout
+	}
+}
+
+// SetWriteTimeout will set the time allowed for each request to write its response, other than the streamed /export.
+// It must be called before SysViewRouter, and a timeout of 0 leaves requests unbounded
+func (s *Server) SetWriteTimeout(writeTimeout time.Duration) {
+	s.writeTimeout = writeTimeout
+}
@@ -0,0 +1,115 @@
+package aceweb
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gkontos/gasket/model"
+	"github.com/gorilla/mux"
+)
+
+// parseNodeType will read a node type from the request body and check the definition
+func parseNodeType(r *http.Request) (model.NodeType, error) {
+	var nodeType model.NodeType
+	if err := ParseJsonRequest(r, &nodeType); err != nil {
+		return nodeType, err
+	}
+	if violations := nodeType.Check(); len(violations) > 0 {
+		return nodeType, &ValidationError{
+			Message:    "Invalid type",
+			Err:        fmt.Errorf("%d schema violations", len(violations)),
+			Violations: violations,
+		}
+	}
+	return nodeType, nil
+}
+
+// TypeCreate will save a new node type
+// router.HandleFunc("/types", TypeCreate).Methods("POST")
+func (s *Server) TypeCreate(w http.ResponseWriter, r *http.Request) {
+	nodeType, err := parseNodeType(r)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if err = s.types.AddType(r.Context(), nodeType); err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	ReturnBodyJSON(w, nodeType, http.StatusCreated)
+}
+
+// TypeList will return every node type
+// router.HandleFunc("/types", TypeList).Methods("GET")
+func (s *Server) TypeList(w http.ResponseWriter, r *http.Request) {
+	types, err := s.types.ListTypes(r.Context())
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	ReturnBodyJSON(w, types, http.StatusOK)
+}
+
+// TypeGet will return the node type with the given name
+// router.HandleFunc("/types/{name}", TypeGet).Methods("GET")
+func (s *Server) TypeGet(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	nodeType, found, err := s.types.GetType(r.Context(), name)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	ReturnBodyJSON(w, nodeType, http.StatusOK)
+}
+
+// TypeUpdate will replace the definition of the node type with the given name
+// router.HandleFunc("/types/{name}", TypeUpdate).Methods("PUT")
+func (s *Server) TypeUpdate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	nodeType, err := parseNodeType(r)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if nodeType.Name != name {
+		ReturnErrorJSON(w, &ValidationError{
+			Err:     fmt.Errorf("Unable to process request"),
+			Message: "Received names do not match",
+		})
+		return
+	}
+
+	found, err := s.types.UpdateType(r.Context(), nodeType)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	ReturnBodyJSON(w, nodeType, http.StatusOK)
+}
+
+// TypeDelete will remove the node type with the given name.  Nodes of the type are not changed
+// router.HandleFunc("/types/{name}", TypeDelete).Methods("DELETE")
+func (s *Server) TypeDelete(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	found, err := s.types.DeleteType(r.Context(), name)
+	if err != nil {
+		ReturnErrorJSON(w, err)
+		return
+	}
+	if !found {
+		ReturnBlankJSON(w, http.StatusNotFound)
+		return
+	}
+	ReturnBlankJSON(w, http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package aceweb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	log "github.com/gkontos/gasket/acelog"
+	internal "github.com/gkontos/gasket/aceweb/internal"
+	"github.com/gkontos/gasket/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var paintingType = model.NodeType{
+	Name: "painting",
+	Properties: map[string]model.PropertyDefinition{
+		"color":   {Datatype: model.DatatypeString},
+		"creator": {Datatype: model.DatatypeString},
+		"style":   {},
+		"year":    {Datatype: model.DatatypeNumber},
+	},
+	Required:  []string{"name", "creator"},
+	Relations: []string{"similarto", "influenced", "inspired"},
+}
+
+func TestTypeCreateController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description: "OK",
+			Url:         "/types",
+			Body: []byte(`{"name":"painting",
+								"properties":{"color":{"datatype":"string"}, "year":{"datatype":"number"}},
+								"required":["name","color"],
+								"relations":["similarto"]}`),
+			ExpectedObject: &model.NodeType{Name: "painting"},
+			ExpectedCode:   http.StatusCreated,
+		}, {
+			Description:    "Already exists",
+			Url:            "/types",
+			Body:           []byte(`{"name":"painting"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusConflict,
+		}, {
+			Description: "Invalid definition",
+			Url:         "/types",
+			Body:        []byte(`{"properties":{"year":{"datatype":"integer"}}, "required":["year","title"]}`),
+			ExpectedObject: []model.Violation{
+				{Pointer: "/name", Message: "is required"},
+				{Pointer: "/properties/year/datatype", Message: "must be one of string, number or boolean"},
+				{Pointer: "/required/1", Message: "must be a declared property"},
+			},
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	internal.RunControllerTests(t, tests, "POST", http.HandlerFunc(srv.TypeCreate),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			log.Debug("running test case : ", tc.Description)
+			assert := assert.New(t)
+			switch expected := tc.ExpectedObject.(type) {
+			case []model.Violation:
+				assertViolations(t, expected, body, tc.Description)
+			case *model.NodeType:
+				var nodeType model.NodeType
+				assert.NoError(json.Unmarshal(body, &nodeType), tc.Description)
+				assert.Equal(expected.Name, nodeType.Name, tc.Description+" -name")
+
+				stored, found, err := srv.types.GetType(context.Background(), expected.Name)
+				assert.NoError(err, tc.Description)
+				assert.True(found, tc.Description+" -stored")
+				assert.Equal(nodeType, stored, tc.Description+" -stored")
+			}
+		})
+}
+
+func TestTypeGetController(t *testing.T) {
+	tests := []internal.ControllerTestCase{
+		{
+			Description:    "Type exists",
+			RouteUrl:       "/types/{name}",
+			Url:            "/types/painting",
+			ExpectedObject: &paintingType,
+			ExpectedCode:   http.StatusOK,
+		}, {
+			Description:    "Does not exist",
+			RouteUrl:       "/types/{name}",
+			Url:            "/types/sculpture",
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusNotFound,
+		},
+	}
+
+	srv := NewServer(internal.MakeTestStore(t))
+	assert.NoError(t, srv.types.AddType(context.Background(), paintingType))
+	internal.RunControllerTests(t, tests, "GET", http.HandlerFunc(srv.TypeGet),
+		func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+			if tc.ExpectedObject != nil {
+				var nodeType model.NodeType
+				assert.NoError(t, json.Unmarshal(body, &nodeType), tc.Description)
+				assert.Equal(t, *tc.ExpectedObject.(*model.NodeType), nodeType, tc.Description)
+			}
+		})
+}
+
+func TestNodeTypeChecks(t *testing.T) {
+	srv := NewServer(internal.MakeTestStore(t))
+	assert.NoError(t, srv.types.AddType(context.Background(), paintingType))
+
+	checkResponse := func(t *testing.T, body []byte, tc internal.ControllerTestCase) {
+		log.Debug("running test case : ", tc.Description)
+		if violations, ok := tc.ExpectedObject.([]model.Violation); ok {
+			var response jsonErr
+			assert.NoError(t, json.Unmarshal(body, &response), tc.Description)
+			assert.Equal(t, violations, response.Violations, tc.Description)
+		}
+	}
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{
+			Description: "Create node not matching type",
+			Url:         "/nodes",
+			Body:        []byte(`{"name":"Guernica", "rdf:type":"painting", "year":"1937", "price":"priceless"}`),
+			ExpectedObject: []model.Violation{
+				{Pointer: "/price", Message: "is not a property of type painting"},
+				{Pointer: "/year", Message: "must be a number"},
+				{Pointer: "/creator", Message: "is required for type painting"},
+			},
+			ExpectedCode: http.StatusUnprocessableEntity,
+		}, {
+			Description:    "Create node matching type",
+			Url:            "/nodes",
+			Body:           []byte(`{"name":"Guernica", "rdf:type":"painting", "year":1937, "creator":"Pablo Picasso"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusCreated,
+		}, {
+			Description:    "Create node without a defined type",
+			Url:            "/nodes",
+			Body:           []byte(`{"name":"David", "rdf:type":"sculpture", "material":"marble"}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusCreated,
+		},
+	}, "POST", http.HandlerFunc(srv.NodeCreate), checkResponse)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{
+			Description:    "Update node not matching type",
+			RouteUrl:       "/nodes/{id}",
+			Url:            "/nodes/123456789",
+			Body:           []byte(`{"year":"1950"}`),
+			ExpectedObject: []model.Violation{{Pointer: "/year", Message: "must be a number"}},
+			ExpectedCode:   http.StatusUnprocessableEntity,
+		}, {
+			Description:    "Update node matching type",
+			RouteUrl:       "/nodes/{id}",
+			Url:            "/nodes/123456789",
+			Body:           []byte(`{"year":1950}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusOK,
+		},
+	}, "PUT", http.HandlerFunc(srv.NodeUpdate), checkResponse)
+
+	internal.RunControllerTests(t, []internal.ControllerTestCase{
+		{
+			Description:    "Relation not allowed by type",
+			Url:            "/relations",
+			Body:           []byte(`{"sourceId":"123456789", "type":"pavedthewayfor", "targetId":"345678901", "label":""}`),
+			ExpectedObject: []model.Violation{{Pointer: "/type", Message: "is not an allowed relation of type painting"}},
+			ExpectedCode:   http.StatusUnprocessableEntity,
+		}, {
+			Description:    "Relation allowed by type",
+			Url:            "/relations",
+			Body:           []byte(`{"sourceId":"123456789", "type":"inspired", "targetId":"345678901", "label":""}`),
+			ExpectedObject: nil,
+			ExpectedCode:   http.StatusCreated,
+		},
+	}, "POST", http.HandlerFunc(srv.RelationCreate), checkResponse)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/aceservice"
+	"github.com/spf13/viper"
+)
+
+// exportCommand will write the contents of the configured store to a file or stdout.
+// usage : gasket export [-format nquads|jsonld|json] [-label l] [-o file]
+// It returns the exit code for the process
+func exportCommand(v *viper.Viper, args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "nquads", "format of the export : nquads, jsonld or json")
+	label := flags.String("label", "", "export only the quads with the label")
+	output := flags.String("o", "-", "file written, or - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket export [-format nquads|jsonld|json] [-label l] [-o file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	_, store := openStore(v)
+	defer store.Close()
+	exporter := aceservice.NewExportService(store)
+	var export func(ctx context.Context, w io.Writer, label string) error
+	switch *format {
+	case "nquads":
+		export = exporter.ExportNQuads
+	case "jsonld":
+		export = exporter.ExportJSONLD
+	case "json":
+		export = exporter.ExportGraph
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Error("Unable to create export file - ", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := export(context.Background(), w, *label); err != nil {
+		log.Error("Export failed - ", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/aceservice"
+	"github.com/gkontos/gasket/model"
+	"github.com/spf13/viper"
+)
+
+// importCommand will load an N-Quads file, or a gasket JSON document as written by the json export, into the configured store.
+// usage : gasket import [-format nquads|json] [-batch n] <file>, where a file of - is read from stdin.
+// It returns the exit code for the process
+func importCommand(v *viper.Viper, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "nquads", "format of the file : nquads or json")
+	batchSize := flags.Int("batch", aceservice.DefaultBatchSize, "number of quads written in each transaction of an nquads import")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket import [-format nquads|json] [-batch n] <file>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || (*format != "nquads" && *format != "json") {
+		flags.Usage()
+		return 2
+	}
+
+	var r io.Reader = os.Stdin
+	if flags.Arg(0) != "-" {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			log.Error("Unable to open import file - ", err)
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	_, store := openStore(v)
+	defer store.Close()
+	importer := aceservice.NewImportService(store)
+	var summary model.ImportSummary
+	var err error
+	if *format == "json" {
+		summary, err = importer.ImportGraph(context.Background(), r)
+	} else {
+		summary, err = importer.ImportNQuads(context.Background(), r, *batchSize, func(progress model.ImportSummary) {
+			log.Info("Imported ", progress.Imported, " quads in ", progress.Batches, " batches")
+		})
+	}
+
+	log.Info("Import of ", flags.Arg(0), " complete : imported=", summary.Imported,
+		" skipped=", summary.Skipped, " invalid=", summary.Invalid)
+	for _, message := range summary.Errors {
+		log.Warn("Not imported : ", message)
+	}
+	if err != nil {
+		log.Error("Import failed - ", err)
+		return 1
+	}
+	return 0
+}
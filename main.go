@@ -1,15 +1,19 @@
 package main
 
 import (
-	"net/http"
+	"flag"
+	"fmt"
 
 	//"github.com/gorilla/handlers"
 	"os"
+	"os/signal"
+	"sort"
+	"syscall"
 
+	"github.com/cayleygraph/cayley"
+	"github.com/gkontos/gasket/aceconfig"
 	"github.com/gkontos/gasket/acedb"
 	log "github.com/gkontos/gasket/acelog"
-	"github.com/gkontos/gasket/aceservice"
-	"github.com/gkontos/gasket/aceweb"
 	"github.com/spf13/viper"
 )
 
@@ -17,36 +21,96 @@ type controller interface {
 	registerRoute()
 }
 
+// command is a subcommand of gasket.  run is called with the configuration and the arguments following the command
+// name, and returns the exit code for the process
+type command struct {
+	run         func(v *viper.Viper, args []string) int
+	description string
+}
+
+// commands are the subcommands of gasket.  serve is run when no command is given
+var commands = map[string]command{
+	"serve":           {serveCommand, "run the server"},
+	"import":          {importCommand, "load an N-Quads or gasket JSON file into the store"},
+	"export":          {exportCommand, "write the store as N-Quads, JSON-LD or gasket JSON"},
+	"validate-config": {validateConfigCommand, "check config.toml and log.toml"},
+	"check":           {checkCommand, "check the integrity of the relations and metadata in the store"},
+	"stats":           {statsCommand, "count the nodes, relations and metadata in the store"},
+}
+
 func main() {
 
-	v := viper.New()
-	v.SetConfigName("config") // no need to include file extension
+	flags := flag.NewFlagSet("gasket", flag.ExitOnError)
+	configDir := flags.String("config", "", "folder holding config.toml and log.toml, in place of "+aceconfig.PathEnv)
+	overrides := aceconfig.Overrides{}
+	flags.Var(overrides, "set", "set a configuration key, ie -set db.backend=bolt.  May be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket [-config dir] [-set key=value]... [command]")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "commands, see gasket <command> -h :")
+		var names []string
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].description)
+		}
+	}
+	flags.Parse(os.Args[1:])
 
-	if _, err := os.Stat(os.Getenv("ACES_CFG")); err == nil {
-		v.AddConfigPath(os.Getenv("ACES_CFG"))
-	} else {
-		v.AddConfigPath("./config") // set the path of your config file
+	name, args := "serve", flags.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown command", name)
+		flags.Usage()
+		os.Exit(2)
 	}
-	err := v.ReadInConfig()
 
+	if *configDir != "" {
+		aceconfig.SetPath(*configDir)
+		if err := log.Reload(); err != nil {
+			log.Warn("Unable to read log configuration from ", *configDir, " - ", err)
+		}
+	}
+	if name != "serve" {
+		// export, stats and check write their results to stdout
+		log.SetOutput(os.Stderr)
+	}
+	v, err := aceconfig.Load("config", configDefaults())
 	if err != nil {
 		log.Fatal("Unable to get configuration - ", err)
 	}
+	overrides.Apply(v)
 
-	aceweb.SetVersion(v.GetString("app.version"))
-	log.Info("Starting Server ", v.GetString("app.version"))
+	os.Exit(cmd.run(v, args))
+}
+
+// reloadLogOnHangup will read log.toml again and reconfigure the application and access logs each time the process receives SIGHUP
+func reloadLogOnHangup() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			if err := log.Reload(); err != nil {
+				log.Error("Unable to reload log configuration - ", err)
+				continue
+			}
+			log.Info("Reloaded log configuration")
+		}
+	}()
+}
 
+// openStore will return the configured datastore along with its handle.  The process exits if the store cannot be opened
+func openStore(v *viper.Viper) (dbhandle.Store, *cayley.Handle) {
 	ds := dbhandle.New()
 	ds.SetConfig(v)
 	graphStore, dberr := ds.GetStore()
 	if dberr != nil {
 		log.Fatal("Unable to get datastore connection - ", dberr)
 	}
-
-	aceservice.SetStore(graphStore)
-
-	router := aceweb.SysViewRouter()
-
-	log.Fatal(http.ListenAndServe(":8080", log.RequestLogHandler(router)))
-
+	return ds, graphStore
 }
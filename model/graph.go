@@ -0,0 +1,14 @@
+package model
+
+// Graph is a subgraph of nodes and the relations between them
+type Graph struct {
+	Nodes     []Node     `json:"nodes"`
+	Relations []Relation `json:"relations"`
+}
+
+// GraphDocument is the contents of a store in the gasket JSON format, as written by the json format of an export
+type GraphDocument struct {
+	Nodes     []Node     `json:"nodes"`
+	Relations []Relation `json:"relations"`
+	Metadata  []Metadata `json:"metadata"`
+}
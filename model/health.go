@@ -0,0 +1,10 @@
+package model
+
+// Health reports whether the server is able to serve requests from its store
+type Health struct {
+	Status  string  `json:"status"`
+	Backend string  `json:"backend,omitempty"`
+	Version string  `json:"version"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
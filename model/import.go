@@ -0,0 +1,10 @@
+package model
+
+// ImportSummary reports the progress and result of loading quads into the store
+type ImportSummary struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Invalid  int      `json:"invalid"`
+	Batches  int      `json:"batches"`
+	Errors   []string `json:"errors,omitempty"`
+}
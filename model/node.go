@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"net/http"
-
 	log "github.com/gkontos/gasket/acelog"
 	"github.com/cayleygraph/cayley/quad"
 )
@@ -18,6 +16,12 @@ type Node struct {
 	Properties map[string]quad.Value
 }
 
+// NodePage is a page of nodes returned from a listing.  Cursor is used to request the following page
+type NodePage struct {
+	Nodes  []Node `json:"nodes"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
 // NodeProperty is a utility struct for mapping quads
 type NodeProperty struct {
 	Predicate quad.IRI   `json:"predicate"`
@@ -128,9 +132,3 @@ func NewProperties(properties ...PropertyValue) map[string]quad.Value {
 	}
 	return propertyMap
 }
-
-// InputValidation should be a common interface used within the controllers
-// https://husobee.github.io/golang/validation/2016/01/08/input-validation.html
-type InputValidation interface {
-	Validate(r *http.Request) error
-}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cayleygraph/cayley/quad"
+)
+
+const TypePredicate = quad.IRI("rdf:type")
+const TypeDefinitionPredicate = quad.IRI("hasTypeDefinition")
+
+// Datatypes of a property within a node type.  A property with no datatype may hold any value
+const (
+	DatatypeString  = "string"
+	DatatypeNumber  = "number"
+	DatatypeBoolean = "boolean"
+)
+
+// NodeType declares the properties and relations of the nodes with an rdf:type of Name.  Properties lists the allowed
+// properties, other properties are only accepted when AdditionalProperties is set.  Relations lists the relation types
+// allowed from the nodes, all relation types are allowed when it is empty
+type NodeType struct {
+	Name                 string                        `json:"name"`
+	Properties           map[string]PropertyDefinition `json:"properties,omitempty"`
+	Required             []string                      `json:"required,omitempty"`
+	AdditionalProperties bool                          `json:"additionalProperties"`
+	Relations            []string                      `json:"relations,omitempty"`
+}
+
+// PropertyDefinition is the definition of a single property of a node type
+type PropertyDefinition struct {
+	Datatype string `json:"datatype,omitempty"`
+}
+
+// TypeID will return the subject the definition of a type is stored under
+func TypeID(name string) quad.IRI {
+	return quad.IRI("type:" + name)
+}
+
+// Check will return the violations within the type definition itself
+func (t NodeType) Check() []Violation {
+	var violations []Violation
+	if t.Name == "" {
+		violations = append(violations, Violation{Pointer: "/name", Message: "is required"})
+	}
+	for _, key := range sortedPropertyNames(t.Properties) {
+		switch t.Properties[key].Datatype {
+		case "", DatatypeString, DatatypeNumber, DatatypeBoolean:
+		default:
+			violations = append(violations, Violation{
+				Pointer: "/properties" + JSONPointer(key) + "/datatype",
+				Message: fmt.Sprintf("must be one of %s, %s or %s", DatatypeString, DatatypeNumber, DatatypeBoolean),
+			})
+		}
+	}
+	for i, key := range t.Required {
+		if _, ok := t.Properties[key]; !ok && !t.AdditionalProperties && key != "name" {
+			violations = append(violations, Violation{
+				Pointer: fmt.Sprintf("/required/%d", i),
+				Message: "must be a declared property",
+			})
+		}
+	}
+	return violations
+}
+
+// ValidateProperties will return a violation for each property which is not allowed by the type or does not have the declared datatype
+func (t NodeType) ValidateProperties(properties map[string]quad.Value) []Violation {
+	var violations []Violation
+	for _, key := range sortedPropertyNames(properties) {
+		if key == string(TypePredicate) {
+			continue
+		}
+		definition, declared := t.Properties[key]
+		if !declared {
+			if !t.AdditionalProperties {
+				violations = append(violations, Violation{
+					Pointer: JSONPointer(key),
+					Message: "is not a property of type " + t.Name,
+				})
+			}
+			continue
+		}
+		if !hasDatatype(properties[key], definition.Datatype) {
+			violations = append(violations, Violation{
+				Pointer: JSONPointer(key),
+				Message: "must be a " + definition.Datatype,
+			})
+		}
+	}
+	return violations
+}
+
+// MissingProperties will return a violation for each required property which is not within properties.  The name
+// of a node is held outside of the properties, so it is checked against name
+func (t NodeType) MissingProperties(name string, properties map[string]quad.Value) []Violation {
+	var violations []Violation
+	for _, key := range t.Required {
+		if key == "name" && name != "" {
+			continue
+		}
+		if _, ok := properties[key]; !ok {
+			violations = append(violations, Violation{Pointer: JSONPointer(key), Message: "is required for type " + t.Name})
+		}
+	}
+	return violations
+}
+
+// AllowsRelation will return true if nodes of the type may have relations of the relation type
+func (t NodeType) AllowsRelation(relationType string) bool {
+	if len(t.Relations) == 0 {
+		return true
+	}
+	for _, allowed := range t.Relations {
+		if allowed == relationType {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDatatype will return true if the property value has the datatype.  Any value has an empty datatype
+func hasDatatype(value quad.Value, datatype string) bool {
+	switch datatype {
+	case DatatypeString:
+		switch value.(type) {
+		case quad.Raw, quad.String, quad.TypedString, quad.LangString:
+			return true
+		}
+		return false
+	case DatatypeNumber:
+		switch value.(type) {
+		case quad.Float, quad.Int:
+			return true
+		}
+		return false
+	case DatatypeBoolean:
+		_, ok := value.(quad.Bool)
+		return ok
+	}
+	return true
+}
+
+func sortedPropertyNames(properties interface{}) []string {
+	var keys []string
+	switch p := properties.(type) {
+	case map[string]PropertyDefinition:
+		for key := range p {
+			keys = append(keys, key)
+		}
+	case map[string]quad.Value:
+		for key := range p {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
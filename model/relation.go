@@ -44,3 +44,23 @@ func (m Relation) MarshalJSON() ([]byte, error) {
 
 	return []byte(jsonString), nil
 }
+
+// UnmarshalJSON will read a JSON object into a relation.  A label is read as a string value
+func (m *Relation) UnmarshalJSON(data []byte) error {
+
+	type Alias Relation
+
+	aux := &struct {
+		Label *string `json:"label"`
+		*Alias
+	}{
+		Alias: (*Alias)(m),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if aux.Label != nil {
+		m.Label = quad.String(*aux.Label)
+	}
+	return nil
+}
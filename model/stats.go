@@ -0,0 +1,27 @@
+package model
+
+// GraphStats counts the objects within a graph store
+type GraphStats struct {
+	Nodes     int   `json:"nodes"`
+	Relations int   `json:"relations"`
+	Metadata  int   `json:"metadata"`
+	Types     int   `json:"types"`
+	Quads     int64 `json:"quads"`
+}
+
+// IntegrityIssue is a problem found by an integrity check of a graph store.  ID is the id of the relation or metadata
+// with the problem
+type IntegrityIssue struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// Kinds of IntegrityIssue
+const (
+	IssueInvalidRelation  = "invalid_relation"
+	IssueMissingQuad      = "missing_relation_quad"
+	IssueMissingNode      = "missing_node"
+	IssueMissingRelation  = "missing_relation"
+	IssueUnlinkedRelation = "unlinked_relation"
+)
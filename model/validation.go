@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// InputValidation should be a common interface used within the controllers
+// https://husobee.github.io/golang/validation/2016/01/08/input-validation.html
+type InputValidation interface {
+	Validate(document interface{}) []Violation
+}
+
+// Violation is a single failure of a request body to match a schema.  Pointer is the JSON pointer to the failing value
+type Violation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// JSON types used within a schema
+const (
+	TypeString = "string"
+	TypeScalar = "scalar" // a string, number or boolean
+)
+
+// Schema is the subset of JSON Schema used by the node, relation and metadata objects.  Properties maps the
+// known properties to their type, any other property must match PropertyPattern and hold a scalar value
+type Schema struct {
+	Properties      map[string]string
+	Nullable        []string
+	Required        []string
+	PropertyPattern *regexp.Regexp
+}
+
+// propertyPattern accepts any property without a '/' character
+var propertyPattern = regexp.MustCompile("^[^/]+$")
+
+// NodeSchema is the schema of a node sent to POST /nodes
+var NodeSchema = Schema{
+	Properties:      map[string]string{"id": TypeString, "name": TypeString, "label": TypeString},
+	Nullable:        []string{"label"},
+	Required:        []string{"name"},
+	PropertyPattern: propertyPattern,
+}
+
+// NodeUpdateSchema is the schema of a node sent to PUT /nodes/{id}.  Only the properties sent are updated, so none are required
+var NodeUpdateSchema = Schema{
+	Properties:      NodeSchema.Properties,
+	Nullable:        NodeSchema.Nullable,
+	PropertyPattern: propertyPattern,
+}
+
+// RelationSchema is the schema of a relation sent to POST /relations
+var RelationSchema = Schema{
+	Properties: map[string]string{"id": TypeString, "sourceId": TypeString, "type": TypeString, "targetId": TypeString, "label": TypeString},
+	Required:   []string{"sourceId", "type", "targetId", "label"},
+}
+
+// RelationUpdateSchema is the schema of a relation sent to PUT /relations/{id}.  Properties which are not sent keep their value
+var RelationUpdateSchema = Schema{
+	Properties: RelationSchema.Properties,
+}
+
+// MetadataSchema is the schema of metadata sent to POST /metadata
+var MetadataSchema = Schema{
+	Properties:      map[string]string{"id": TypeString, "relationId": TypeString},
+	Required:        []string{"relationId"},
+	PropertyPattern: propertyPattern,
+}
+
+// MetadataUpdateSchema is the schema of metadata sent to PUT /metadata/{metadataid}
+var MetadataUpdateSchema = Schema{
+	Properties:      MetadataSchema.Properties,
+	PropertyPattern: propertyPattern,
+}
+
+// Validate will return every violation of the schema within the document.  The document is a decoded JSON value
+func (s Schema) Validate(document interface{}) []Violation {
+	var violations []Violation
+
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return append(violations, Violation{Pointer: "", Message: "must be an object"})
+	}
+
+	for _, key := range s.Required {
+		if _, ok := object[key]; !ok {
+			violations = append(violations, Violation{Pointer: JSONPointer(key), Message: "is required"})
+		}
+	}
+
+	var keys []string
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := object[key]
+		valueType, known := s.Properties[key]
+		switch {
+		case known && value == nil && s.nullable(key):
+		case known && !hasType(value, valueType):
+			violations = append(violations, Violation{Pointer: JSONPointer(key), Message: "must be a " + valueType})
+		case known:
+		case s.PropertyPattern == nil:
+			// properties which are not part of the schema are ignored
+		case !s.PropertyPattern.MatchString(key):
+			violations = append(violations, Violation{
+				Pointer: JSONPointer(key),
+				Message: fmt.Sprintf("property name must match %s", s.PropertyPattern),
+			})
+		case !hasType(value, TypeScalar):
+			violations = append(violations, Violation{Pointer: JSONPointer(key), Message: "must be a string, number or boolean"})
+		}
+	}
+	return violations
+}
+
+func (s Schema) nullable(key string) bool {
+	for _, nullable := range s.Nullable {
+		if nullable == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasType will return true if the decoded JSON value is of the schema type
+func hasType(value interface{}, valueType string) bool {
+	switch value.(type) {
+	case string:
+		return true
+	case float64, bool:
+		return valueType == TypeScalar
+	}
+	return false
+}
+
+// JSONPointer will return the JSON pointer to a property of the document root
+func JSONPointer(key string) string {
+	return "/" + strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gkontos/gasket/aceconfig"
+	"github.com/gkontos/gasket/acedb"
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/acemetrics"
+	service "github.com/gkontos/gasket/aceservice"
+	"github.com/gkontos/gasket/aceweb"
+	"github.com/spf13/viper"
+)
+
+// Defaults for the [server] block of the configuration
+const (
+	DefaultAddress            = ":8080"
+	DefaultReadTimeout        = 30 * time.Second
+	DefaultWriteTimeout       = 60 * time.Second
+	DefaultExportWriteTimeout = time.Duration(0)
+	DefaultIdleTimeout        = 120 * time.Second
+	DefaultShutdownTimeout    = 30 * time.Second
+)
+
+// configDefaults will return the value of each config.toml key which is used when the key is not set
+func configDefaults() map[string]interface{} {
+	return map[string]interface{}{
+		"app.version":                 "v0",
+		"db.backend":                  dbhandle.DefaultBackend,
+		"db.retry.attempts":           dbhandle.DefaultRetryAttempts,
+		"db.retry.initial_interval":   dbhandle.DefaultRetryInitialInterval,
+		"db.retry.max_interval":       dbhandle.DefaultRetryMaxInterval,
+		"auth.enabled":                false,
+		"auth.roles_claim":            aceweb.DefaultRolesClaim,
+		"server.address":              DefaultAddress,
+		"server.read_timeout":         DefaultReadTimeout,
+		"server.write_timeout":        DefaultWriteTimeout,
+		"server.export_write_timeout": DefaultExportWriteTimeout,
+		"server.idle_timeout":         DefaultIdleTimeout,
+		"server.shutdown_timeout":     DefaultShutdownTimeout,
+		"server.ready_timeout":        aceweb.DefaultReadyTimeout,
+	}
+}
+
+// duration will return the configured duration for key, or def when the key is not set
+func duration(v *viper.Viper, key string, def time.Duration) time.Duration {
+	if !v.IsSet(key) {
+		return def
+	}
+	return v.GetDuration(key)
+}
+
+// serveCommand will run the server until the process is stopped.  usage : gasket serve
+// It returns the exit code for the process
+func serveCommand(v *viper.Viper, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket serve")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	log.WithFields(log.Fields(aceconfig.Redacted(v))).Info("Effective configuration")
+
+	reloadLogOnHangup()
+
+	aceweb.SetVersion(v.GetString("app.version"))
+	log.Info("Starting Server ", v.GetString("app.version"))
+
+	ds, graphStore := openStore(v)
+
+	authenticator, err := aceweb.NewAuthenticator(v)
+	if err != nil {
+		log.Fatal("Unable to configure authentication - ", err)
+	}
+	if authenticator == nil {
+		log.Warn("Authentication is disabled, set auth.enabled to require bearer tokens")
+	}
+
+	if err = acemetrics.RegisterStore(graphStore); err != nil {
+		log.Error("Unable to register store metrics - ", err)
+	}
+
+	srv := aceweb.NewServer(graphStore)
+	readyTimeout := duration(v, "server.ready_timeout", aceweb.DefaultReadyTimeout)
+	srv.SetHealthCheck(ds.Backend(), readyTimeout)
+	srv.SetWriteTimeout(duration(v, "server.write_timeout", DefaultWriteTimeout))
+	router := aceweb.SysViewRouter(srv)
+
+	// metrics and health checks are served without authentication so they can be scraped and probed
+	handler := http.NewServeMux()
+	handler.Handle("/metrics", acemetrics.Handler())
+	handler.HandleFunc("/healthz", srv.Healthz)
+	handler.HandleFunc("/readyz", srv.Readyz)
+	handler.Handle("/", log.RequestLogHandler(aceweb.AuthHandler(authenticator, router)))
+
+	// the connection is checked in the background and reopened when it is lost
+	stopMonitor := make(chan struct{})
+	if interval := v.GetDuration("db.retry.check_interval"); interval > 0 {
+		health := service.NewHealthService(graphStore)
+		go ds.Monitor(interval, func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+			defer cancel()
+			_, err := health.Ping(ctx)
+			return err
+		}, stopMonitor)
+	}
+
+	closeStore := func() {
+		close(stopMonitor)
+		graphStore.Close()
+	}
+	if err = serve(v, newHTTPServer(v, handler), closeStore); err != nil {
+		log.Error("Server stopped - ", err)
+		return 1
+	}
+	log.Info("Server stopped")
+	return 0
+}
+
+// newHTTPServer will return a server for the handler listening on server.address with the configured timeouts.
+// server.write_timeout is applied to each route by the router, while the write deadline of a connection must leave
+// time for the longest response: it is the larger of server.write_timeout and server.export_write_timeout, and
+// there is no deadline when either is 0
+func newHTTPServer(v *viper.Viper, handler http.Handler) *http.Server {
+	address := v.GetString("server.address")
+	if address == "" {
+		address = DefaultAddress
+	}
+	writeTimeout := duration(v, "server.write_timeout", DefaultWriteTimeout)
+	exportTimeout := duration(v, "server.export_write_timeout", DefaultExportWriteTimeout)
+	if writeTimeout <= 0 || exportTimeout <= 0 {
+		writeTimeout = 0
+	} else if exportTimeout > writeTimeout {
+		writeTimeout = exportTimeout
+	}
+	return &http.Server{
+		Addr:         address,
+		Handler:      handler,
+		ReadTimeout:  duration(v, "server.read_timeout", DefaultReadTimeout),
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  duration(v, "server.idle_timeout", DefaultIdleTimeout),
+	}
+}
+
+// serve will run the server until the process receives SIGINT or SIGTERM.  In-flight requests are given
+// server.shutdown_timeout to complete before closeStore is called.  TLS is used when server.tls.cert and server.tls.key are set
+func serve(v *viper.Viper, srv *http.Server, closeStore func()) error {
+	certFile := v.GetString("server.tls.cert")
+	keyFile := v.GetString("server.tls.key")
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("server.tls.cert and server.tls.key must both be set to use TLS")
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	errs := make(chan error, 1)
+	go func() {
+		if certFile != "" {
+			log.Info("Listening on ", srv.Addr, " with TLS")
+			errs <- srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			log.Info("Listening on ", srv.Addr)
+			errs <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		closeStore()
+		return err
+	case sig := <-stop:
+		log.Info("Received ", sig, ", shutting down")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration(v, "server.shutdown_timeout", DefaultShutdownTimeout))
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		log.Error("Requests did not complete before shutdown - ", err)
+	}
+	closeStore()
+	log.Info("Datastore closed")
+	return err
+}
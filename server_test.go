@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeTLSPair(t *testing.T) {
+	for _, keys := range []map[string]string{
+		{"server.tls.cert": "server.crt"},
+		{"server.tls.key": "server.key"},
+	} {
+		v := viper.New()
+		for key, value := range keys {
+			v.Set(key, value)
+		}
+		closed := false
+		err := serve(v, &http.Server{Addr: "127.0.0.1:0"}, func() { closed = true })
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "must both be set")
+		}
+		assert.False(t, closed, "the store is not used when the configuration is refused")
+	}
+}
+
+func TestServeShutdown(t *testing.T) {
+	assert := assert.New(t)
+
+	// a free port for the server
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var mu sync.Mutex
+	var events []string
+	event := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, name)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		event("request completed")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	v := viper.New()
+	v.Set("server.shutdown_timeout", "5s")
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(v, &http.Server{Addr: addr, Handler: handler}, func() { event("store closed") })
+	}()
+
+	responses := make(chan int, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get("http://" + addr)
+			if err == nil {
+				resp.Body.Close()
+				responses <- resp.StatusCode
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		responses <- 0
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request was not received")
+	}
+	if err = syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// the store stays open while the request is in flight
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	assert.Empty(events)
+	mu.Unlock()
+
+	close(release)
+	assert.Equal(http.StatusOK, <-responses)
+	select {
+	case err = <-served:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not stop")
+	}
+	assert.Equal([]string{"request completed", "store closed"}, events)
+}
+
+func TestNewHTTPServerWriteTimeout(t *testing.T) {
+	tests := []struct {
+		Description string
+		Config      map[string]string
+		Expected    time.Duration
+	}{
+		{"exports are not bounded by default", map[string]string{}, 0},
+		{"export timeout longer than the write timeout", map[string]string{"server.export_write_timeout": "30m"}, 30 * time.Minute},
+		{"export timeout shorter than the write timeout", map[string]string{"server.export_write_timeout": "10s"}, DefaultWriteTimeout},
+		{"no write timeout", map[string]string{"server.write_timeout": "0s", "server.export_write_timeout": "30m"}, 0},
+	}
+	for _, tc := range tests {
+		v := viper.New()
+		for key, value := range tc.Config {
+			v.Set(key, value)
+		}
+		assert.Equal(t, tc.Expected, newHTTPServer(v, http.NotFoundHandler()).WriteTimeout, tc.Description)
+	}
+}
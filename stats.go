@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	log "github.com/gkontos/gasket/acelog"
+	"github.com/gkontos/gasket/aceservice"
+	"github.com/spf13/viper"
+)
+
+// statsCommand will print the number of nodes, relations, metadata, types and quads within the configured store.
+// usage : gasket stats [-json]
+// It returns the exit code for the process
+func statsCommand(v *viper.Viper, args []string) int {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the counts as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket stats [-json]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	_, store := openStore(v)
+	defer store.Close()
+	stats, err := aceservice.NewStatsService(store).Stats(context.Background())
+	if err != nil {
+		log.Error("Unable to count the store - ", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(stats)
+	}
+	fmt.Printf("nodes      %d\nrelations  %d\nmetadata   %d\ntypes      %d\nquads      %d\n",
+		stats.Nodes, stats.Relations, stats.Metadata, stats.Types, stats.Quads)
+	return 0
+}
+
+// checkCommand will print the integrity problems found within the configured store.  usage : gasket check [-json]
+// It returns 1 when a problem is found
+func checkCommand(v *viper.Viper, args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the problems as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket check [-json]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	_, store := openStore(v)
+	defer store.Close()
+	issues, err := aceservice.NewStatsService(store).Check(context.Background())
+	if err != nil {
+		log.Error("Unable to check the store - ", err)
+		return 1
+	}
+	if *asJSON {
+		if code := printJSON(issues); code != 0 {
+			return code
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s\t%s\t%s\n", issue.Kind, issue.ID, issue.Message)
+		}
+		fmt.Printf("%d problems found\n", len(issues))
+	}
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printJSON will write v to stdout as indented JSON
+func printJSON(v interface{}) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Error("Unable to write JSON - ", err)
+		return 1
+	}
+	return 0
+}
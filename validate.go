@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gkontos/gasket/aceconfig"
+	"github.com/gkontos/gasket/acedb"
+	"github.com/gkontos/gasket/aceweb"
+	"github.com/spf13/viper"
+)
+
+// durationKeys are the config.toml keys which must hold a duration such as "30s"
+var durationKeys = []string{
+	"server.read_timeout", "server.write_timeout", "server.export_write_timeout", "server.idle_timeout", "server.shutdown_timeout", "server.ready_timeout",
+	"db.retry.initial_interval", "db.retry.max_interval", "db.retry.check_interval", "db.retry.drain_timeout",
+}
+
+// validateConfigCommand will check config.toml and log.toml without opening the store or the listener.
+// usage : gasket validate-config [-print], where -print writes the effective configuration with secrets redacted.
+// It returns 1 when a problem is found
+func validateConfigCommand(v *viper.Viper, args []string) int {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	printConfig := flags.Bool("print", false, "print the effective configuration, with passwords, secrets and uris redacted")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : gasket validate-config [-print]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var problems []string
+	if _, err := aceconfig.Load("log", nil); err != nil {
+		problems = append(problems, fmt.Sprint("log.toml : ", err))
+	}
+
+	ds := dbhandle.New()
+	ds.SetConfig(v)
+	if err := ds.Validate(); err != nil {
+		problems = append(problems, fmt.Sprint("db : ", err))
+	}
+	if _, err := aceweb.NewAuthenticator(v); err != nil {
+		problems = append(problems, fmt.Sprint("auth : ", err))
+	}
+
+	cert, key := v.GetString("server.tls.cert"), v.GetString("server.tls.key")
+	if (cert == "") != (key == "") {
+		problems = append(problems, "server.tls : cert and key must both be set to use TLS")
+	}
+	for _, file := range []string{cert, key} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			problems = append(problems, fmt.Sprint("server.tls : ", err))
+		}
+	}
+	for _, key := range durationKeys {
+		if !v.IsSet(key) {
+			continue
+		}
+		if _, err := time.ParseDuration(v.GetString(key)); err != nil {
+			problems = append(problems, fmt.Sprint(key, " : ", err))
+		}
+	}
+
+	if *printConfig {
+		settings := aceconfig.Redacted(v)
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s = %v\n", key, settings[key])
+		}
+	}
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(problems))
+		return 1
+	}
+	fmt.Println("configuration is valid")
+	return 0
+}